@@ -2,25 +2,34 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 )
 
 const (
+	// DynAPIPrefix is the default endpoint used by new clients.
 	DynAPIPrefix = "https://api.dynect.net/REST"
 )
 
 var (
 	PollingInterval  = 1 * time.Second
 	ErrPromotedToJob = errors.New("promoted to job")
-	ErrRateLimited   = errors.New("too many requests")
+	// ErrRateLimited matches, with errors.Is, the *Error returned once
+	// the retry policy of a rate limited request is exhausted.
+	ErrRateLimited = errors.New("too many requests")
+	// ErrStagedChangesLost matches, with errors.Is, the error returned when
+	// the session expired before publishing a zone: Dyn keeps the changes
+	// to publish in the session, so they were lost with it.
+	ErrStagedChangesLost = errors.New("changes staged in the session were lost")
 )
 
 // handleJobRedirect overrides the net/http.DefaultClient's redirection policy
@@ -47,15 +56,28 @@ func handleJobRedirect(req *http.Request, via []*http.Request) error {
 type Client struct {
 	Token        string
 	CustomerName string
-	transport    *http.Transport
-	verbose      bool
-	mutex        sync.Mutex
+	// Endpoint is the base URL of the DynECT REST API, without a trailing
+	// slash. It defaults to DynAPIPrefix.
+	Endpoint string
+	// Retry is the policy applied to requests rejected with a 429 status.
+	// It defaults to DefaultRetryPolicy.
+	Retry     RetryPolicy
+	transport *http.Transport
+	verbose   bool
+	mutex     sync.Mutex
+	// Credentials of the current session, used to log in again when it
+	// expires.
+	username string
+	password string
+	lastUsed time.Time
 }
 
 // Creates a new Httpclient.
 func NewClient(customerName string) *Client {
 	return &Client{
 		CustomerName: customerName,
+		Endpoint:     DynAPIPrefix,
+		Retry:        DefaultRetryPolicy,
 		transport:    &http.Transport{Proxy: http.ProxyFromEnvironment},
 		mutex:        sync.Mutex{},
 	}
@@ -73,20 +95,58 @@ func (c *Client) Verbose(p bool) {
 
 // Establishes a new session with the DynECT API.
 func (c *Client) Login(username, password string) error {
-	var req = LoginBlock{
-		Username:     username,
-		Password:     password,
-		CustomerName: c.CustomerName}
+	return c.LoginContext(context.Background(), username, password)
+}
 
-	var resp LoginResponse
+// LoginContext establishes a new session with the DynECT API, and can be
+// cancelled through ctx.
+//
+// The credentials are kept by the client, so that it can log in again when
+// the session expires.
+func (c *Client) LoginContext(ctx context.Context, username, password string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.login(ctx, username, password)
+}
 
-	err := c.Do("POST", "Session", req, &resp)
-	if err != nil {
-		return err
+// Set the base URL of the DynECT REST API, e.g. to talk to a staging proxy or
+// to a local stand-in server. A trailing slash is ignored, and an empty
+// endpoint restores DynAPIPrefix.
+func (c *Client) SetEndpoint(endpoint string) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if endpoint == "" {
+		endpoint = DynAPIPrefix
 	}
+	c.Endpoint = endpoint
+}
 
-	c.Token = resp.Data.Token
-	return nil
+// url builds the absolute URL of an API endpoint, e.g. "Zone/example.com".
+func (c *Client) url(endpoint string) string {
+	prefix := c.Endpoint
+	if prefix == "" {
+		prefix = DynAPIPrefix
+	}
+	return fmt.Sprintf("%s/%s", prefix, strings.TrimPrefix(endpoint, "/"))
+}
+
+// jobURL turns the Location header of a 307 response into an absolute URL
+// on the configured endpoint.
+//
+// The API usually answers with a path such as "/REST/Job/1234", but absolute
+// URLs pointing at the public API are rewritten too, so that job polling goes
+// through the same endpoint as the original request.
+func (c *Client) jobURL(loc string) string {
+	for _, prefix := range []string{c.Endpoint, DynAPIPrefix} {
+		if prefix != "" && strings.HasPrefix(loc, prefix+"/") {
+			return c.url(strings.TrimPrefix(loc, prefix+"/"))
+		}
+	}
+	if u, err := url.Parse(loc); err == nil && u.IsAbs() {
+		loc = u.Path
+	}
+	loc = strings.TrimPrefix(loc, "/")
+	loc = strings.TrimPrefix(loc, "REST/")
+	return c.url(loc)
 }
 
 func (c *Client) LoggedIn() bool {
@@ -94,7 +154,20 @@ func (c *Client) LoggedIn() bool {
 }
 
 func (c *Client) Logout() error {
-	return c.Do("DELETE", "Session", nil, nil)
+	return c.LogoutContext(context.Background())
+}
+
+// LogoutContext closes the session, and can be cancelled through ctx.
+func (c *Client) LogoutContext(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.do(ctx, "DELETE", "Session", nil, nil); err != nil {
+		return err
+	}
+	c.Token = ""
+	c.username = ""
+	c.password = ""
+	return nil
 }
 
 // newRequest creates a new *http.Request, and sets the following headers:
@@ -102,14 +175,14 @@ func (c *Client) Logout() error {
 // <li>Auth-Token</li>
 // <li>Content-Type</li>
 // </ul>
-func (c *Client) newRequest(method, urlStr string, data []byte) (*http.Request, error) {
+func (c *Client) newRequest(ctx context.Context, method, urlStr string, data []byte) (*http.Request, error) {
 	var r *http.Request
 	var err error
 
 	if data != nil {
-		r, err = http.NewRequest(method, urlStr, bytes.NewReader(data))
+		r, err = http.NewRequestWithContext(ctx, method, urlStr, bytes.NewReader(data))
 	} else {
-		r, err = http.NewRequest(method, urlStr, nil)
+		r, err = http.NewRequestWithContext(ctx, method, urlStr, nil)
 	}
 	if err != nil {
 		return r, err
@@ -122,8 +195,42 @@ func (c *Client) newRequest(method, urlStr string, data []byte) (*http.Request,
 }
 
 func (c *Client) Do(method, endpoint string, requestData, responseData interface{}) error {
+	return c.DoContext(context.Background(), method, endpoint, requestData, responseData)
+}
+
+// DoContext performs a request against the DynECT API, like Do.
+//
+// The context is attached to every HTTP request, including the ones polling
+// a job when the request is promoted to one, so cancelling it or reaching its
+// deadline stops the request and returns the context's error.
+//
+// When the API reports that the session is invalid or expired, the client
+// logs in again with the credentials given to Login, and replays the request
+// once. Zone publishes are not replayed, as the changes staged in the expired
+// session were lost with it: they fail with ErrStagedChangesLost instead.
+func (c *Client) DoContext(ctx context.Context, method, endpoint string, requestData, responseData interface{}) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+
+	err := c.do(ctx, method, endpoint, requestData, responseData)
+	if !isSessionExpired(err) || !c.canRenewSession(method, endpoint) {
+		return err
+	}
+	if zone, ok := publishedZone(method, endpoint, requestData); ok {
+		return fmt.Errorf("%w: the session expired before publishing zone %s: %s", ErrStagedChangesLost, zone, err)
+	}
+
+	log.Printf("[INFO] dynect: session expired during %s %s, logging in again", method, endpoint)
+	if err := c.login(ctx, c.username, c.password); err != nil {
+		return fmt.Errorf("failed to renew expired session: %s", err)
+	}
+	return c.do(ctx, method, endpoint, requestData, responseData)
+}
+
+// do performs a request, without locking the client.
+func (c *Client) do(ctx context.Context, method, endpoint string, requestData, responseData interface{}) error {
+	c.lastUsed = time.Now()
+
 	// Throw an error if the user tries to make a request if the client is
 	// logged out/unauthenticated, but make an exemption for when the
 	// caller is trying to log in.
@@ -148,29 +255,15 @@ func (c *Client) Do(method, endpoint string, requestData, responseData interface
 		return err
 	}
 
-	urlStr := fmt.Sprintf("%s/%s", DynAPIPrefix, endpoint)
-
-	// Create a new http.Request.
-	req, err := c.newRequest(method, urlStr, js)
-	if err != nil {
-		return err
-	}
+	urlStr := c.url(endpoint)
 
 	if c.verbose {
 		log.Printf("Making %s request to %q", method, urlStr)
 	}
 
-	var resp *http.Response
-	resp, err = c.transport.RoundTrip(req)
-
+	// Send the request, retrying it while it is rate limited.
+	resp, err := c.roundTrip(ctx, method, urlStr, js)
 	if err != nil {
-		if c.verbose {
-			respBody, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				return err
-			}
-			log.Printf("%s", string(respBody))
-		}
 		return err
 	}
 	defer resp.Body.Close()
@@ -208,34 +301,39 @@ func (c *Client) Do(method, endpoint string, requestData, responseData interface
 		// Since a URL is technically a URI, we should do some checks
 		// on the returned URI to sanitize it, and make sure that it is
 		// in the format we would like it to be.
-		if strings.HasPrefix(loc, "/REST/") {
-			loc = strings.TrimLeft(loc, "/REST/")
-		}
-		if !strings.HasPrefix(loc, DynAPIPrefix) {
-			loc = fmt.Sprintf("%s/%s", DynAPIPrefix, loc)
-		}
+		loc = c.jobURL(loc)
 
 		log.Println("Fetching location:", loc)
 
 		// Generate a new request.
-		req, err := c.newRequest("GET", loc, nil)
+		req, err := c.newRequest(ctx, "GET", loc, nil)
 		if err != nil {
 			return err
 		}
 
 		var jobData JobData
 
-		// Poll the API endpoint, until we get a response back.
+		// Poll the API endpoint, until we get a response back, or until
+		// the context is done.
 		for {
 			select {
+			case <-ctx.Done():
+				return ctx.Err()
 			case <-time.After(PollingInterval):
 				resp, err := c.transport.RoundTrip(req)
 				if err != nil {
 					return err
 				}
-				defer resp.Body.Close()
+				if resp.StatusCode == 429 {
+					// Polling too fast, wait for the next
+					// tick.
+					resp.Body.Close()
+					log.Printf("[WARN] dynect: polling of %s was rate limited", loc)
+					continue
+				}
 
 				text, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
 				//log.Println(string(text))
 				if err != nil {
 					return fmt.Errorf("Could not read response body: %s", err)
@@ -263,24 +361,22 @@ func (c *Client) Do(method, endpoint string, requestData, responseData interface
 					}
 					return nil
 				case "failure":
-					return fmt.Errorf("request failed: %v", jobData.Messages)
+					return &Error{
+						StatusCode: resp.StatusCode,
+						Status:     jobData.Status,
+						JobID:      jobData.ID,
+						Messages:   jobData.Messages,
+					}
 				}
 			}
 		}
 
-		return nil
-
-	case 429:
-		return ErrRateLimited
 	}
 
-	// If we got here, this means that the client does not know how to
-	// interpret the response, and it should just error out.
+	// If we got here, the API rejected the request.
 	reason, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read in response body")
 	}
-	return fmt.Errorf("server responded with %v: %v",
-		resp.Status,
-		string(reason))
+	return newError(resp.StatusCode, reason)
 }
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientJobURL(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		location string
+		expected string
+	}{
+		{DynAPIPrefix, "/REST/Job/1234", "https://api.dynect.net/REST/Job/1234"},
+		{DynAPIPrefix, "Job/1234", "https://api.dynect.net/REST/Job/1234"},
+		{DynAPIPrefix, "https://api.dynect.net/REST/Job/1234", "https://api.dynect.net/REST/Job/1234"},
+		{"http://127.0.0.1:8080/REST", "/REST/Job/1234", "http://127.0.0.1:8080/REST/Job/1234"},
+		{"http://127.0.0.1:8080/REST", "https://api.dynect.net/REST/Job/1234", "http://127.0.0.1:8080/REST/Job/1234"},
+		{"http://proxy.local/dyn", "/REST/Job/1234", "http://proxy.local/dyn/Job/1234"},
+	}
+
+	for _, tc := range cases {
+		c := NewClient("customer")
+		c.SetEndpoint(tc.endpoint)
+		if got := c.jobURL(tc.location); got != tc.expected {
+			t.Errorf("jobURL(%q) on %q: expected %q, got %q", tc.location, tc.endpoint, tc.expected, got)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MinWait: time.Second, MaxWait: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for retry, max := range expected {
+		wait := policy.backoff(retry)
+		if wait > max || wait < max/2 {
+			t.Errorf("backoff(%d): expected a wait between %s and %s, got %s", retry, max/2, max, wait)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 14, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"Mon, 14 Jun 2021 12:00:10 GMT", 10 * time.Second, true},
+		{"Mon, 14 Jun 2021 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tc := range cases {
+		wait, ok := retryAfter(tc.value, now)
+		if wait != tc.expected || ok != tc.ok {
+			t.Errorf("retryAfter(%q): expected %s, %t, got %s, %t", tc.value, tc.expected, tc.ok, wait, ok)
+		}
+	}
+}
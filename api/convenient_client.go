@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -18,49 +21,174 @@ func NewConvenientClient(customerName string) *ConvenientClient {
 	return &ConvenientClient{
 		Client{
 			CustomerName: customerName,
+			Endpoint:     DynAPIPrefix,
+			Retry:        DefaultRetryPolicy,
 			transport:    &http.Transport{Proxy: http.ProxyFromEnvironment},
 		}}
 }
 
 // PublishZone Publish a specific zone and the changes for the current session
+//
+// Every change staged in the session is published, whoever made it: the
+// caller must make sure the session holds no other changes, e.g. with
+// GetZoneChanges.
 func (c *ConvenientClient) PublishZone(zone string) error {
+	return c.PublishZoneContext(context.Background(), zone)
+}
+
+// PublishZoneContext is PublishZone, with a context to cancel the request
+func (c *ConvenientClient) PublishZoneContext(ctx context.Context, zone string) error {
+	return c.PublishZoneNotesContext(ctx, zone, "")
+}
+
+// PublishZoneNotes is PublishZone, with notes shown in the change history of
+// the zone
+func (c *ConvenientClient) PublishZoneNotes(zone, notes string) error {
+	return c.PublishZoneNotesContext(context.Background(), zone, notes)
+}
+
+// PublishZoneNotesContext is PublishZoneNotes, with a context to cancel the
+// request
+func (c *ConvenientClient) PublishZoneNotesContext(ctx context.Context, zone, notes string) error {
 	data := &PublishZoneBlock{
 		Publish: true,
+		Notes:   notes,
+	}
+	return c.DoContext(ctx, "PUT", "Zone/"+zone, data, nil)
+}
+
+// GetZoneChanges Method to list the changes made to a zone in the session,
+// which are not published yet
+func (c *ConvenientClient) GetZoneChanges(zone string) ([]ZoneChange, error) {
+	return c.GetZoneChangesContext(context.Background(), zone)
+}
+
+// GetZoneChangesContext is GetZoneChanges, with a context to cancel the
+// request
+func (c *ConvenientClient) GetZoneChangesContext(ctx context.Context, zone string) ([]ZoneChange, error) {
+	var response ZoneChangesResponse
+	if err := c.DoContext(ctx, "GET", "ZoneChanges/"+zone, nil, &response); err != nil {
+		return nil, err
 	}
-	return c.Do("PUT", "Zone/"+zone, data, nil)
+	return response.Data, nil
+}
+
+// DiscardZoneChanges Method to discard the changes made to a zone in the
+// session, which are not published yet
+func (c *ConvenientClient) DiscardZoneChanges(zone string) error {
+	return c.DiscardZoneChangesContext(context.Background(), zone)
+}
+
+// DiscardZoneChangesContext is DiscardZoneChanges, with a context to cancel
+// the request
+func (c *ConvenientClient) DiscardZoneChangesContext(ctx context.Context, zone string) error {
+	return c.DoContext(ctx, "DELETE", "ZoneChanges/"+zone, nil, nil)
 }
 
-// GetRecordID finds the dns record ID by fetching all records for a FQDN
+// GetRecordID finds the dns record ID by fetching all records for a FQDN.
+//
+// When several records of the type share the FQDN, the one whose data
+// matches the Value (or RData) of the record is picked, then its TTL if set.
 func (c *ConvenientClient) GetRecordID(record *Record) error {
-	finalID := ""
+	return c.GetRecordIDContext(context.Background(), record)
+}
+
+// GetRecordIDContext is GetRecordID, with a context to cancel the request
+func (c *ConvenientClient) GetRecordIDContext(ctx context.Context, record *Record) error {
 	url := fmt.Sprintf("AllRecord/%s/%s", record.Zone, record.FQDN)
 	var records AllRecordsResponse
-	err := c.Do("GET", url, nil, &records)
+	err := c.DoContext(ctx, "GET", url, nil, &records)
 	if err != nil {
 		return fmt.Errorf("Failed to find Dyn record id: %s", err)
 	}
+	var candidates []string
 	for _, recordURL := range records.Data {
 		id := strings.TrimPrefix(recordURL, fmt.Sprintf("/REST/%sRecord/%s/%s/", record.Type, record.Zone, record.FQDN))
 		if !strings.Contains(id, "/") && id != "" {
-			finalID = id
-			log.Printf("[INFO] Found Dyn record ID: %s", id)
+			candidates = append(candidates, id)
 		}
 	}
-	if finalID == "" {
+	if len(candidates) == 0 {
 		return fmt.Errorf("Failed to find Dyn record id!")
 	}
+	if len(candidates) == 1 {
+		log.Printf("[INFO] Found Dyn record ID: %s", candidates[0])
+		record.ID = candidates[0]
+		return nil
+	}
 
-	record.ID = finalID
+	if record.Value == "" && record.RData == nil {
+		return fmt.Errorf("Found %d %s records for %s (IDs %s), give the ID or the value of the record",
+			len(candidates), record.Type, record.FQDN, strings.Join(candidates, ", "))
+	}
+	id, err := c.matchRecordID(ctx, record, candidates)
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Found Dyn record ID: %s", id)
+	record.ID = id
 	return nil
 }
 
-// CreateRecord Method to create a DNS record
-func (c *ConvenientClient) CreateRecord(record *Record) error {
-	if record.FQDN == "" && record.Name == "" {
-		record.FQDN = record.Zone
-	} else if record.FQDN == "" {
-		record.FQDN = fmt.Sprintf("%s.%s", record.Name, record.Zone)
+// matchRecordID returns the ID of the candidate record whose data matches
+// the record.
+func (c *ConvenientClient) matchRecordID(ctx context.Context, record *Record, candidates []string) (string, error) {
+	rdata, err := buildRData(record)
+	if err != nil {
+		return "", err
+	}
+	value, err := FormatRData(record.Type, rdata)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []BaseRecord
+	for _, id := range candidates {
+		url := fmt.Sprintf("%sRecord/%s/%s/%s", record.Type, record.Zone, record.FQDN, id)
+		var rec RecordResponse
+		if err := c.DoContext(ctx, "GET", url, nil, &rec); err != nil {
+			return "", err
+		}
+		candidate, err := FormatRData(record.Type, rec.Data.RData)
+		if err != nil {
+			return "", err
+		}
+		// Domain names may be returned with a trailing dot
+		if strings.TrimSuffix(candidate, ".") == strings.TrimSuffix(value, ".") {
+			matches = append(matches, rec.Data)
+		}
+	}
+
+	if len(matches) > 1 && record.TTL != "" {
+		var sameTTL []BaseRecord
+		for _, m := range matches {
+			if strconv.Itoa(m.TTL) == record.TTL {
+				sameTTL = append(sameTTL, m)
+			}
+		}
+		matches = sameTTL
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("Failed to find Dyn %s record id for %s with value %q", record.Type, record.FQDN, value)
+	case 1:
+		return strconv.Itoa(matches[0].RecordId), nil
+	default:
+		return "", fmt.Errorf("Found %d identical %s records for %s with value %q, give the ID of the record",
+			len(matches), record.Type, record.FQDN, value)
 	}
+}
+
+// CreateRecord Method to create a DNS record. The ID of the record is set
+// from the response.
+func (c *ConvenientClient) CreateRecord(record *Record) error {
+	return c.CreateRecordContext(context.Background(), record)
+}
+
+// CreateRecordContext is CreateRecord, with a context to cancel the request
+func (c *ConvenientClient) CreateRecordContext(ctx context.Context, record *Record) error {
+	setRecordFQDN(record)
 	rdata, err := buildRData(record)
 	if err != nil {
 		return fmt.Errorf("Failed to create Dyn RData: %s", err)
@@ -70,14 +198,24 @@ func (c *ConvenientClient) CreateRecord(record *Record) error {
 		RData: rdata,
 		TTL:   record.TTL,
 	}
-	return c.Do("POST", url, data, nil)
+	var rec RecordResponse
+	if err := c.DoContext(ctx, "POST", url, data, &rec); err != nil {
+		return err
+	}
+	if rec.Data.RecordId != 0 {
+		record.ID = strconv.Itoa(rec.Data.RecordId)
+	}
+	return nil
 }
 
 // UpdateRecord Method to update a DNS record
 func (c *ConvenientClient) UpdateRecord(record *Record) error {
-	if record.FQDN == "" {
-		record.FQDN = fmt.Sprintf("%s.%s", record.Name, record.Zone)
-	}
+	return c.UpdateRecordContext(context.Background(), record)
+}
+
+// UpdateRecordContext is UpdateRecord, with a context to cancel the request
+func (c *ConvenientClient) UpdateRecordContext(ctx context.Context, record *Record) error {
+	setRecordFQDN(record)
 	rdata, err := buildRData(record)
 	if err != nil {
 		return fmt.Errorf("Failed to create Dyn RData: %s", err)
@@ -87,94 +225,306 @@ func (c *ConvenientClient) UpdateRecord(record *Record) error {
 		RData: rdata,
 		TTL:   record.TTL,
 	}
-	return c.Do("PUT", url, data, nil)
+	var rec RecordResponse
+	if err := c.DoContext(ctx, "PUT", url, data, &rec); err != nil {
+		return err
+	}
+	if rec.Data.RecordId != 0 {
+		record.ID = strconv.Itoa(rec.Data.RecordId)
+	}
+	return nil
 }
 
 // DeleteRecord Method to delete a DNS record
 func (c *ConvenientClient) DeleteRecord(record *Record) error {
-	if record.FQDN == "" {
-		record.FQDN = fmt.Sprintf("%s.%s", record.Name, record.Zone)
-	}
+	return c.DeleteRecordContext(context.Background(), record)
+}
+
+// DeleteRecordContext is DeleteRecord, with a context to cancel the request
+func (c *ConvenientClient) DeleteRecordContext(ctx context.Context, record *Record) error {
+	setRecordFQDN(record)
 	// safety check that we have an ID, otherwise we could accidentally delete everything
 	if record.ID == "" {
 		return fmt.Errorf("No ID found! We can't continue!")
 	}
 	url := fmt.Sprintf("%sRecord/%s/%s/%s", record.Type, record.Zone, record.FQDN, record.ID)
-	return c.Do("DELETE", url, nil, nil)
+	return c.DoContext(ctx, "DELETE", url, nil, nil)
 }
 
 // GetRecord Method to get record details
 func (c *ConvenientClient) GetRecord(record *Record) error {
+	return c.GetRecordContext(context.Background(), record)
+}
+
+// GetRecordContext is GetRecord, with a context to cancel the request
+func (c *ConvenientClient) GetRecordContext(ctx context.Context, record *Record) error {
 	url := fmt.Sprintf("%sRecord/%s/%s/%s", record.Type, record.Zone, record.FQDN, record.ID)
 	var rec RecordResponse
-	err := c.Do("GET", url, nil, &rec)
+	err := c.DoContext(ctx, "GET", url, nil, &rec)
 	if err != nil {
 		return err
 	}
 
-	record.Zone = rec.Data.Zone
-	record.FQDN = rec.Data.FQDN
-	record.Name = strings.TrimSuffix(rec.Data.FQDN, "."+rec.Data.Zone)
-	record.Type = rec.Data.RecordType
-	record.TTL = strconv.Itoa(rec.Data.TTL)
-
-	switch rec.Data.RecordType {
-	case "A", "AAAA":
-		record.Value = rec.Data.RData.Address
-	case "ALIAS":
-		record.Value = rec.Data.RData.Alias
-	case "CNAME":
-		record.Value = rec.Data.RData.CName
-	case "MX":
-		record.Value = fmt.Sprintf("%d %s", rec.Data.RData.Preference, rec.Data.RData.Exchange)
-	case "NS":
-		record.Value = rec.Data.RData.NSDName
-	case "SOA":
-		record.Value = rec.Data.RData.RName
-	case "TXT", "SPF":
-		record.Value = rec.Data.RData.TxtData
-	default:
-		fmt.Println("unknown response", rec)
-		return fmt.Errorf("Invalid Dyn record type: %s", rec.Data.RecordType)
+	return loadRecord(record, &rec.Data)
+}
+
+// setRecordFQDN fills in the FQDN of a record from its name, an empty name
+// standing for the apex of the zone.
+func setRecordFQDN(record *Record) {
+	if record.FQDN == "" && record.Name == "" {
+		record.FQDN = record.Zone
+	} else if record.FQDN == "" {
+		record.FQDN = fmt.Sprintf("%s.%s", record.Name, record.Zone)
+	}
+}
+
+// loadRecord sets the details of a record from the data returned by the API
+func loadRecord(record *Record, data *BaseRecord) error {
+	record.Zone = data.Zone
+	record.FQDN = data.FQDN
+	if data.FQDN == data.Zone {
+		record.Name = ""
+	} else {
+		record.Name = strings.TrimSuffix(data.FQDN, "."+data.Zone)
 	}
+	record.Type = data.RecordType
+	record.TTL = strconv.Itoa(data.TTL)
+
+	value, err := FormatRData(data.RecordType, data.RData)
+	if err != nil {
+		return err
+	}
+	record.Value = value
+	record.RData = &data.RData
 
 	return nil
 }
 
-func buildRData(r *Record) (DataBlock, error) {
-	var rdata DataBlock
+// GetAllRecords Method to get the details of all the records of a zone, or
+// of a node when fqdn is not empty. Records of types not supported by
+// FormatRData are skipped.
+func (c *ConvenientClient) GetAllRecords(zone, fqdn string) ([]Record, error) {
+	return c.GetAllRecordsContext(context.Background(), zone, fqdn)
+}
 
-	switch r.Type {
-	case "A", "AAAA":
-		rdata = DataBlock{
-			Address: r.Value,
-		}
-	case "ALIAS":
-		rdata = DataBlock{
-			Alias: r.Value,
-		}
-	case "CNAME":
-		rdata = DataBlock{
-			CName: r.Value,
+// GetAllRecordsContext is GetAllRecords, with a context to cancel the request
+func (c *ConvenientClient) GetAllRecordsContext(ctx context.Context, zone, fqdn string) ([]Record, error) {
+	url := "AllRecord/" + zone
+	if fqdn != "" {
+		url += "/" + fqdn
+	}
+	requestData := struct {
+		Detail string `json:"detail"`
+	}{Detail: "Y"}
+	var response AllRecordsDetailedResponse
+	if err := c.DoContext(ctx, "GET", url, requestData, &response); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, key := range sortedRecordKeys(response.Data) {
+		for i := range response.Data[key] {
+			var record Record
+			if err := loadRecord(&record, &response.Data[key][i]); err != nil {
+				log.Printf("[WARN] Skipping Dyn record %d: %s", response.Data[key][i].RecordId, err)
+				continue
+			}
+			record.ID = strconv.Itoa(response.Data[key][i].RecordId)
+			records = append(records, record)
 		}
-	case "MX":
-		rdata = DataBlock{}
-		fmt.Sscanf(r.Value, "%d %s", &rdata.Preference, &rdata.Exchange)
-	case "NS":
-		rdata = DataBlock{
-			NSDName: r.Value,
+	}
+	return records, nil
+}
+
+func sortedRecordKeys(m map[string][]BaseRecord) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func buildRData(r *Record) (DataBlock, error) {
+	if r.RData != nil {
+		return *r.RData, nil
+	}
+	return ParseRData(r.Type, r.Value)
+}
+
+// GetRecordSet fetches all the records of a type at a node. Values is empty
+// when there are none.
+func (c *ConvenientClient) GetRecordSet(rs *RecordSet) error {
+	return c.GetRecordSetContext(context.Background(), rs)
+}
+
+// GetRecordSetContext is GetRecordSet, with a context to cancel the request
+func (c *ConvenientClient) GetRecordSetContext(ctx context.Context, rs *RecordSet) error {
+	url := fmt.Sprintf("%sRecord/%s/%s", rs.Type, rs.Zone, rs.FQDN)
+	var records AllRecordsResponse
+	if err := c.DoContext(ctx, "GET", url, nil, &records); err != nil {
+		return err
+	}
+
+	rs.Values = make([]string, 0, len(records.Data))
+	for _, recordURL := range records.Data {
+		var rec RecordResponse
+		if err := c.DoContext(ctx, "GET", strings.TrimPrefix(recordURL, "/REST/"), nil, &rec); err != nil {
+			return err
 		}
-	case "SOA":
-		rdata = DataBlock{
-			RName: r.Value,
+		value, err := FormatRData(rec.Data.RecordType, rec.Data.RData)
+		if err != nil {
+			return err
 		}
-	case "TXT", "SPF":
-		rdata = DataBlock{
-			TxtData: r.Value,
+		rs.Values = append(rs.Values, value)
+		rs.TTL = strconv.Itoa(rec.Data.TTL)
+	}
+
+	return nil
+}
+
+// ReplaceRecordSet replaces all the records of a type at a node with the
+// given values, in a single change.
+func (c *ConvenientClient) ReplaceRecordSet(rs *RecordSet) error {
+	return c.ReplaceRecordSetContext(context.Background(), rs)
+}
+
+// ReplaceRecordSetContext is ReplaceRecordSet, with a context to cancel the
+// request
+func (c *ConvenientClient) ReplaceRecordSetContext(ctx context.Context, rs *RecordSet) error {
+	records := make([]RecordRequest, 0, len(rs.Values))
+	for _, value := range rs.Values {
+		rdata, err := ParseRData(rs.Type, value)
+		if err != nil {
+			return fmt.Errorf("Failed to create Dyn RData: %s", err)
 		}
-	default:
-		return rdata, fmt.Errorf("Invalid Dyn record type: %s", r.Type)
+		records = append(records, RecordRequest{RData: rdata, TTL: rs.TTL})
 	}
 
-	return rdata, nil
+	url := fmt.Sprintf("%sRecord/%s/%s", rs.Type, rs.Zone, rs.FQDN)
+	data := map[string][]RecordRequest{rs.Type + "Records": records}
+	return c.DoContext(ctx, "PUT", url, data, nil)
+}
+
+// DeleteRecordSet deletes all the records of a type at a node.
+func (c *ConvenientClient) DeleteRecordSet(rs *RecordSet) error {
+	return c.DeleteRecordSetContext(context.Background(), rs)
+}
+
+// DeleteRecordSetContext is DeleteRecordSet, with a context to cancel the
+// request
+func (c *ConvenientClient) DeleteRecordSetContext(ctx context.Context, rs *RecordSet) error {
+	url := fmt.Sprintf("%sRecord/%s/%s", rs.Type, rs.Zone, rs.FQDN)
+	return c.DoContext(ctx, "DELETE", url, nil, nil)
+}
+
+// CreateZone Method to create a primary zone. The zone must be published
+// afterwards.
+func (c *ConvenientClient) CreateZone(zone *Zone) error {
+	return c.CreateZoneContext(context.Background(), zone)
+}
+
+// CreateZoneContext is CreateZone, with a context to cancel the request
+func (c *ConvenientClient) CreateZoneContext(ctx context.Context, zone *Zone) error {
+	data := &CreateZoneBlock{
+		RName:       zone.RName,
+		SerialStyle: zone.SerialStyle,
+		TTL:         zone.TTL,
+	}
+	return c.DoContext(ctx, "POST", "Zone/"+zone.Name, data, nil)
+}
+
+// GetZone Method to get zone details, including its SOA record
+func (c *ConvenientClient) GetZone(zone *Zone) error {
+	return c.GetZoneContext(context.Background(), zone)
+}
+
+// GetZoneContext is GetZone, with a context to cancel the request
+func (c *ConvenientClient) GetZoneContext(ctx context.Context, zone *Zone) error {
+	var response ZoneResponse
+	if err := c.DoContext(ctx, "GET", "Zone/"+zone.Name, nil, &response); err != nil {
+		return err
+	}
+	zone.Name = response.Data.Zone
+	zone.Serial = response.Data.Serial
+	zone.SerialStyle = response.Data.SerialStyle
+	zone.Type = response.Data.ZoneType
+
+	soa, err := c.getSOARecord(ctx, zone.Name)
+	if err != nil {
+		return err
+	}
+	zone.RName = soa.RData.RName
+	zone.TTL = soa.TTL
+	return nil
+}
+
+// UpdateZone Method to update the contact, TTL and serial style of a zone,
+// through its SOA record. The zone must be published afterwards.
+func (c *ConvenientClient) UpdateZone(zone *Zone) error {
+	return c.UpdateZoneContext(context.Background(), zone)
+}
+
+// UpdateZoneContext is UpdateZone, with a context to cancel the request
+func (c *ConvenientClient) UpdateZoneContext(ctx context.Context, zone *Zone) error {
+	soa, err := c.getSOARecord(ctx, zone.Name)
+	if err != nil {
+		return err
+	}
+	rdata := soa.RData
+	rdata.RName = zone.RName
+	data := &UpdateSOABlock{
+		RData:       rdata,
+		SerialStyle: zone.SerialStyle,
+	}
+	if zone.TTL > 0 {
+		data.TTL = strconv.Itoa(zone.TTL)
+	}
+	url := fmt.Sprintf("SOARecord/%s/%s/%d", zone.Name, zone.Name, soa.RecordId)
+	return c.DoContext(ctx, "PUT", url, data, nil)
+}
+
+// DeleteZone Method to delete a zone, with all its records
+func (c *ConvenientClient) DeleteZone(zone *Zone) error {
+	return c.DeleteZoneContext(context.Background(), zone)
+}
+
+// DeleteZoneContext is DeleteZone, with a context to cancel the request
+func (c *ConvenientClient) DeleteZoneContext(ctx context.Context, zone *Zone) error {
+	return c.DoContext(ctx, "DELETE", "Zone/"+zone.Name, nil, nil)
+}
+
+// getSOARecord fetches the SOA record of a zone
+func (c *ConvenientClient) getSOARecord(ctx context.Context, zone string) (*BaseRecord, error) {
+	var records AllRecordsResponse
+	if err := c.DoContext(ctx, "GET", fmt.Sprintf("SOARecord/%s/%s", zone, zone), nil, &records); err != nil {
+		return nil, err
+	}
+	if len(records.Data) == 0 {
+		return nil, fmt.Errorf("Failed to find the SOA record of zone %s", zone)
+	}
+	var rec RecordResponse
+	if err := c.DoContext(ctx, "GET", strings.TrimPrefix(records.Data[0], "/REST/"), nil, &rec); err != nil {
+		return nil, err
+	}
+	return &rec.Data, nil
+}
+
+// GetZones Method to list the names of the zones of the customer
+func (c *ConvenientClient) GetZones() ([]string, error) {
+	return c.GetZonesContext(context.Background())
+}
+
+// GetZonesContext is GetZones, with a context to cancel the request
+func (c *ConvenientClient) GetZonesContext(ctx context.Context) ([]string, error) {
+	var response ZonesResponse
+	if err := c.DoContext(ctx, "GET", "Zone/", nil, &response); err != nil {
+		return nil, err
+	}
+	zones := make([]string, 0, len(response.Data))
+	for _, zoneURL := range response.Data {
+		// e.g. /REST/Zone/example.com/
+		zones = append(zones, path.Base(strings.TrimSuffix(zoneURL, "/")))
+	}
+	return zones, nil
 }
@@ -1,5 +1,7 @@
 package api
 
+import "encoding/json"
+
 // DSFSResponse is used for holding the data returned by a call to
 // "https://api.dynect.net/REST/DSF/" with 'detail: Y'.
 type AllDSFDetailedResponse struct {
@@ -31,6 +33,10 @@ type DSFServiceRequest struct {
 	PublishBlock
 	Label string `json:"label"`
 	TTL   SInt   `json:"ttl"`
+	// Nodes and Rulesets replace those of the service when set, the rulesets
+	// being sent with their whole tree of response pools down to the records
+	Nodes    *[]DSFNode    `json:"nodes,omitempty"`
+	Rulesets *[]DSFRuleset `json:"rulesets,omitempty"`
 }
 
 type DSFResponsePoolRef struct {
@@ -40,16 +46,45 @@ type DSFRulesetRequest struct {
 	PublishBlock
 	Label        string                `json:"label"`
 	CriteriaType string                `json:"criteria_type"`
+	Criteria     *DSFCriteria          `json:"criteria,omitempty"`
+	Ordering     string                `json:"ordering,omitempty"`
 	ResponsePool *[]DSFResponsePoolRef `json:"response_pools"`
 }
+
+// DSFCriteria holds the criteria of a ruleset, depending on its criteria type
+type DSFCriteria struct {
+	GeoIP *DSFGeoIPCriteria `json:"geoip,omitempty"`
+}
+
+// DSFGeoIPCriteria matches the clients located in one of the regions,
+// countries or provinces
+type DSFGeoIPCriteria struct {
+	Region   []string `json:"region,omitempty"`
+	Country  []string `json:"country,omitempty"`
+	Province []string `json:"province,omitempty"`
+}
+
+// UnmarshalJSON accepts the empty criteria of "always" rulesets, which are
+// not always an object
+func (c *DSFCriteria) UnmarshalJSON(b []byte) error {
+	*c = DSFCriteria{}
+	if len(b) == 0 || b[0] != '{' {
+		return nil
+	}
+	type criteria DSFCriteria
+	return json.Unmarshal(b, (*criteria)(c))
+}
+
+// DSFRuleset and the objects nested in it are also sent within a
+// DSFServiceRequest, so their read-only fields are omitted when empty.
 type DSFRuleset struct {
-	ID            string            `json:"dsf_ruleset_id"`
+	ID            string            `json:"dsf_ruleset_id,omitempty"`
 	Label         string            `json:"label"`
 	CriteriaType  string            `json:"criteria_type"`
-	Criteria      interface{}       `json:"criteria"`
-	Ordering      string            `json:"ordering"`
-	Eligible      string            `json:"eligible"`
-	PendingChange string            `json:"pending_change"`
+	Criteria      DSFCriteria       `json:"criteria"`
+	Ordering      string            `json:"ordering,omitempty"`
+	Eligible      string            `json:"eligible,omitempty"`
+	PendingChange string            `json:"pending_change,omitempty"`
 	ResponsePools []DSFResponsePool `json:"response_pools"`
 }
 type DSFRulesetResponse struct {
@@ -57,6 +92,18 @@ type DSFRulesetResponse struct {
 	Data DSFRuleset `json:"data"`
 }
 
+type AllDSFRulesetsResponse struct {
+	ResponseBlock
+	Data []DSFRuleset `json:"data"`
+}
+
+// DSFRulesetOrderingRequest moves a ruleset to a position in the evaluation
+// order of its service, leaving the rest of the ruleset as is.
+type DSFRulesetOrderingRequest struct {
+	PublishBlock
+	Ordering string `json:"ordering"`
+}
+
 type DSFResponsePoolResponse struct {
 	ResponseBlock
 	Data DSFResponsePool `json:"data"`
@@ -64,73 +111,73 @@ type DSFResponsePoolResponse struct {
 type DSFResponsePoolRequest struct {
 	PublishBlock
 	Label      string `json:"label"`
-	Automation string `json:"automation",omit_empty`
+	Automation string `json:"automation,omitempty"`
 }
 type DSFResponsePool struct {
-	ID            string              `json:"dsf_response_pool_id"`
+	ID            string              `json:"dsf_response_pool_id,omitempty"`
 	Label         string              `json:"label"`
 	Automation    string              `json:"automation"`
-	CoreSetCount  string              `json:"core_set_count"`
-	Eligible      string              `json:"eligible"`
-	PendingChange string              `json:"pending_change"`
+	CoreSetCount  string              `json:"core_set_count,omitempty"`
+	Eligible      string              `json:"eligible,omitempty"`
+	PendingChange string              `json:"pending_change,omitempty"`
 	RsChains      []DSFRecordSetChain `json:"rs_chains"`
-	Rulesets      []DSFRuleset        `json:"rulesets"`
-	Status        string              `json:"status"`
-	LastMonitored string              `json:"last_monitored"`
-	Notifier      string              `json:"notifier"`
+	Rulesets      []DSFRuleset        `json:"rulesets,omitempty"`
+	Status        string              `json:"status,omitempty"`
+	LastMonitored string              `json:"last_monitored,omitempty"`
+	Notifier      string              `json:"notifier,omitempty"`
 }
 
 type DSFRecordSetChain struct {
-	ID                string         `json:"dsf_record_set_failover_chain_id"`
-	Status            string         `json:"status"`
+	ID                string         `json:"dsf_record_set_failover_chain_id,omitempty"`
+	Status            string         `json:"status,omitempty"`
 	Core              string         `json:"core"`
 	Label             string         `json:"label"`
-	DSFResponsePoolID string         `json:"dsf_response_pool_id"`
-	DSFServiceID      string         `json:"service_id"`
-	PendingChange     string         `json:"pending_change"`
+	DSFResponsePoolID string         `json:"dsf_response_pool_id,omitempty"`
+	DSFServiceID      string         `json:"service_id,omitempty"`
+	PendingChange     string         `json:"pending_change,omitempty"`
 	DSFRecordSets     []DSFRecordSet `json:"record_sets"`
 }
 
 type DSFRecordSet struct {
-	Status        string      `json:"status"`
+	Status        string      `json:"status,omitempty"`
 	Eligible      SBool       `json:"eligible"`
-	ID            string      `json:"dsf_record_set_id"`
-	MonitorID     string      `json:"dsf_monitor_id"`
+	ID            string      `json:"dsf_record_set_id,omitempty"`
+	MonitorID     string      `json:"dsf_monitor_id,omitempty"`
 	Label         string      `json:"label"`
-	TroubleCount  SInt        `json:"trouble_count"`
+	TroubleCount  SInt        `json:"trouble_count,omitempty"`
 	Records       []DSFRecord `json:"records"`
-	FailCount     SInt        `json:"fail_count"`
-	TorpidityMax  string      `json:"torpidity_max"`
-	TTLDerived    string      `json:"ttl_derived"`
-	LastMonitored string      `json:"last_monitored"`
-	TTL           SInt        `json:"ttl"`
-	ServiceID     string      `json:"service_id"`
-	ServeCount    SInt        `json:"serve_count"`
+	FailCount     SInt        `json:"fail_count,omitempty"`
+	TorpidityMax  string      `json:"torpidity_max,omitempty"`
+	TTLDerived    string      `json:"ttl_derived,omitempty"`
+	LastMonitored string      `json:"last_monitored,omitempty"`
+	TTL           SInt        `json:"ttl,omitempty"`
+	ServiceID     string      `json:"service_id,omitempty"`
+	ServeCount    SInt        `json:"serve_count,omitempty"`
 	Automation    string      `json:"automation"`
-	PendingChange string      `json:"pending_change"`
+	PendingChange string      `json:"pending_change,omitempty"`
 	RDataClass    string      `json:"rdata_class"`
 }
 
 type DSFRecord struct {
-	Status         string   `json:"status"`
-	Endpoints      []string `json:"endpoints"`
-	RDataClass     string   `json:"rdata_class"`
+	Status         string   `json:"status,omitempty"`
+	Endpoints      []string `json:"endpoints,omitempty"`
+	RDataClass     string   `json:"rdata_class,omitempty"`
 	Weight         int      `json:"weight"`
 	Eligible       SBool    `json:"eligible"`
-	ID             string   `json:"dsf_record_id"`
-	DSFRecordSetID string   `json:"dsf_record_set_id"`
+	ID             string   `json:"dsf_record_id,omitempty"`
+	DSFRecordSetID string   `json:"dsf_record_set_id,omitempty"`
 	//RData           interface{} `json:"rdata"`
-	EndpointUpCount int    `json:"endpoint_up_count"`
+	EndpointUpCount int    `json:"endpoint_up_count,omitempty"`
 	Label           string `json:"label"`
 	MasterLine      string `json:"master_line"`
-	Torpidity       int    `json:"torpidity"`
-	LastMonitored   int    `json:"last_monitored"`
-	TTL             string `json:"ttl"`
-	DSFServiceID    string `json:"service_id"`
-	PendingChange   string `json:"pending_change"`
+	Torpidity       int    `json:"torpidity,omitempty"`
+	LastMonitored   int    `json:"last_monitored,omitempty"`
+	TTL             string `json:"ttl,omitempty"`
+	DSFServiceID    string `json:"service_id,omitempty"`
+	PendingChange   string `json:"pending_change,omitempty"`
 	Automation      string `json:"automation"`
-	ReponseTime     int    `json:"response_time"`
-	Publish         string `json:"publish",omit_empty`
+	ReponseTime     int    `json:"response_time,omitempty"`
+	Publish         string `json:"publish,omitempty"`
 }
 
 type DSFNodeRequest struct {
@@ -163,6 +210,11 @@ type DSFRsfcResponse struct {
 	ResponseBlock
 	Data DSFRecordSetChain `json:"data"`
 }
+
+type AllDSFRsfcResponse struct {
+	ResponseBlock
+	Data []DSFRecordSetChain `json:"data"`
+}
 type DSFRecordSetRequest struct {
 	PublishBlock
 	Label          string  `json:"label"`
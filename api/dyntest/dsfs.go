@@ -0,0 +1,844 @@
+package dyntest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+)
+
+// serviceState holds a Traffic Director service as a flat set of objects,
+// which are nested back together when rendered.
+type serviceState struct {
+	service    api.DSFService
+	rulesets   []*rulesetState
+	pools      map[string]*api.DSFResponsePool
+	chains     map[string]*api.DSFRecordSetChain
+	recordSets map[string]*recordSetState
+	records    map[string]*api.DSFRecord
+	// Notes sent with each publish
+	notes []string
+}
+
+type rulesetState struct {
+	ruleset api.DSFRuleset
+	poolIDs []string
+}
+
+type recordSetState struct {
+	recordSet api.DSFRecordSet
+	chainID   string
+	poolID    string
+}
+
+func (s *Server) newDSFID() string {
+	return fmt.Sprintf("%08x", s.newID())
+}
+
+// ServicePendingChange reports whether a service has unpublished changes.
+func (s *Server) ServicePendingChange(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc, ok := s.services[id]
+	return ok && svc.service.PendingChange == "Y"
+}
+
+// ServicePublishNotes returns the notes sent with each publish of a service.
+func (s *Server) ServicePublishNotes(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if svc, ok := s.services[id]; ok {
+		return append([]string(nil), svc.notes...)
+	}
+	return nil
+}
+
+// track records whether a change to the service was published.
+func (svc *serviceState) track(publish api.PublishBlock) {
+	if publish.Publish {
+		svc.service.PendingChange = ""
+		svc.notes = append(svc.notes, publish.Notes)
+	} else {
+		svc.service.PendingChange = "Y"
+	}
+}
+
+func (svc *serviceState) render() api.DSFService {
+	service := svc.service
+	service.Rulesets = make([]api.DSFRuleset, len(svc.rulesets))
+	for i, rs := range svc.rulesets {
+		service.Rulesets[i] = svc.renderRuleset(rs)
+	}
+	return service
+}
+
+func (svc *serviceState) renderRuleset(rs *rulesetState) api.DSFRuleset {
+	ruleset := rs.ruleset
+	ruleset.ResponsePools = []api.DSFResponsePool{}
+	for _, id := range rs.poolIDs {
+		if pool, ok := svc.pools[id]; ok {
+			ruleset.ResponsePools = append(ruleset.ResponsePools, svc.renderPool(pool))
+		}
+	}
+	return ruleset
+}
+
+func (svc *serviceState) renderPool(p *api.DSFResponsePool) api.DSFResponsePool {
+	pool := *p
+	pool.RsChains = []api.DSFRecordSetChain{}
+	for _, chain := range svc.sortedChains() {
+		if chain.DSFResponsePoolID == pool.ID {
+			pool.RsChains = append(pool.RsChains, svc.renderChain(chain))
+		}
+	}
+	return pool
+}
+
+func (svc *serviceState) renderChain(c *api.DSFRecordSetChain) api.DSFRecordSetChain {
+	chain := *c
+	chain.DSFRecordSets = []api.DSFRecordSet{}
+	for _, rs := range svc.sortedRecordSets() {
+		if rs.chainID == chain.ID {
+			chain.DSFRecordSets = append(chain.DSFRecordSets, svc.renderRecordSet(rs))
+		}
+	}
+	return chain
+}
+
+func (svc *serviceState) renderRecordSet(rs *recordSetState) api.DSFRecordSet {
+	set := rs.recordSet
+	set.Records = []api.DSFRecord{}
+	for _, record := range svc.sortedRecords() {
+		if record.DSFRecordSetID == set.ID {
+			set.Records = append(set.Records, *record)
+		}
+	}
+	return set
+}
+
+func (svc *serviceState) sortedChains() []*api.DSFRecordSetChain {
+	chains := make([]*api.DSFRecordSetChain, 0, len(svc.chains))
+	for _, id := range sortedKeys(svc.chains) {
+		chains = append(chains, svc.chains[id])
+	}
+	return chains
+}
+
+func (svc *serviceState) sortedRecordSets() []*recordSetState {
+	sets := make([]*recordSetState, 0, len(svc.recordSets))
+	for _, id := range sortedKeys(svc.recordSets) {
+		sets = append(sets, svc.recordSets[id])
+	}
+	return sets
+}
+
+func (svc *serviceState) sortedRecords() []*api.DSFRecord {
+	records := make([]*api.DSFRecord, 0, len(svc.records))
+	for _, id := range sortedKeys(svc.records) {
+		records = append(records, svc.records[id])
+	}
+	return records
+}
+
+func (s *Server) serveDSF(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	if len(args) == 0 {
+		switch r.Method {
+		case "GET":
+			var request struct {
+				Detail string `json:"detail"`
+			}
+			if !decode(w, body, &request) {
+				return
+			}
+			detail := request.Detail == "Y" || r.URL.Query().Get("detail") == "Y"
+			if detail {
+				services := []api.DSFService{}
+				for _, id := range sortedKeys(s.services) {
+					services = append(services, s.services[id].render())
+				}
+				writeSuccess(w, services)
+				return
+			}
+			uris := []string{}
+			for _, id := range sortedKeys(s.services) {
+				uris = append(uris, "/REST/DSF/"+id)
+			}
+			writeSuccess(w, uris)
+		case "POST":
+			var request api.DSFServiceRequest
+			if !decode(w, body, &request) {
+				return
+			}
+			svc := &serviceState{
+				service: api.DSFService{
+					ID:        s.newDSFID(),
+					Label:     request.Label,
+					TTL:       request.TTL,
+					Active:    "Y",
+					Notifiers: []api.Notifier{},
+					Nodes:     []api.DSFNode{},
+				},
+				pools:      map[string]*api.DSFResponsePool{},
+				chains:     map[string]*api.DSFRecordSetChain{},
+				recordSets: map[string]*recordSetState{},
+				records:    map[string]*api.DSFRecord{},
+			}
+			s.updateService(svc, &request)
+			svc.track(request.PublishBlock)
+			s.services[svc.service.ID] = svc
+			writeSuccess(w, svc.render())
+		default:
+			writeMethodError(w, r)
+		}
+		return
+	}
+
+	svc, ok := s.services[args[0]]
+	if !ok {
+		writeNotFound(w, "service")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.render())
+	case "PUT":
+		var request api.DSFServiceRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		if request.Label != "" {
+			svc.service.Label = request.Label
+		}
+		if request.TTL != 0 {
+			svc.service.TTL = request.TTL
+		}
+		s.updateService(svc, &request)
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.render())
+	case "DELETE":
+		delete(s.services, args[0])
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+// updateService replaces the nodes and the tree of rulesets of a service
+// with those of the request, when set.
+func (s *Server) updateService(svc *serviceState, request *api.DSFServiceRequest) {
+	if request.Nodes != nil {
+		svc.service.Nodes = append([]api.DSFNode{}, *request.Nodes...)
+	}
+	if request.Rulesets == nil {
+		return
+	}
+
+	svc.rulesets = nil
+	svc.pools = map[string]*api.DSFResponsePool{}
+	svc.chains = map[string]*api.DSFRecordSetChain{}
+	svc.recordSets = map[string]*recordSetState{}
+	svc.records = map[string]*api.DSFRecord{}
+	for _, ruleset := range *request.Rulesets {
+		rs := &rulesetState{
+			ruleset: api.DSFRuleset{
+				ID:           s.newDSFID(),
+				Label:        ruleset.Label,
+				CriteriaType: defaultString(ruleset.CriteriaType, "always"),
+				Criteria:     ruleset.Criteria,
+				Eligible:     "true",
+			},
+		}
+		for _, p := range ruleset.ResponsePools {
+			pool := &api.DSFResponsePool{
+				ID:         s.newDSFID(),
+				Label:      p.Label,
+				Automation: defaultString(p.Automation, "auto"),
+				Eligible:   "true",
+				Status:     "ok",
+			}
+			svc.pools[pool.ID] = pool
+			rs.poolIDs = append(rs.poolIDs, pool.ID)
+			for _, c := range p.RsChains {
+				chain := &api.DSFRecordSetChain{
+					ID:                s.newDSFID(),
+					Status:            "ok",
+					Core:              defaultString(c.Core, "false"),
+					Label:             c.Label,
+					DSFResponsePoolID: pool.ID,
+					DSFServiceID:      svc.service.ID,
+				}
+				svc.chains[chain.ID] = chain
+				for _, r := range c.DSFRecordSets {
+					set := &recordSetState{recordSet: r, chainID: chain.ID, poolID: pool.ID}
+					set.recordSet.ID = s.newDSFID()
+					set.recordSet.Status = "ok"
+					set.recordSet.ServiceID = svc.service.ID
+					set.recordSet.Automation = defaultString(r.Automation, "auto")
+					set.recordSet.Records = nil
+					svc.recordSets[set.recordSet.ID] = set
+					for _, rec := range r.Records {
+						record := rec
+						record.ID = s.newDSFID()
+						record.Status = "ok"
+						record.Endpoints = []string{}
+						record.DSFRecordSetID = set.recordSet.ID
+						record.DSFServiceID = svc.service.ID
+						record.Automation = defaultString(rec.Automation, "auto")
+						svc.records[record.ID] = &record
+					}
+				}
+			}
+		}
+		svc.rulesets = append(svc.rulesets, rs)
+	}
+	svc.renumberRulesets()
+}
+
+func (s *Server) serveDSFNode(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	if len(args) != 1 {
+		writeNotFound(w, "service")
+		return
+	}
+	svc, ok := s.services[args[0]]
+	if !ok {
+		writeNotFound(w, "service")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.service.Nodes)
+	case "PUT":
+		var request api.DSFNodeRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		svc.service.Nodes = append([]api.DSFNode{}, request.Node...)
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.service.Nodes)
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+// lookupService returns the service named by the first path argument.
+func (s *Server) lookupService(w http.ResponseWriter, args []string) (*serviceState, bool) {
+	if len(args) == 0 {
+		writeNotFound(w, "service")
+		return nil, false
+	}
+	svc, ok := s.services[args[0]]
+	if !ok {
+		writeNotFound(w, "service")
+	}
+	return svc, ok
+}
+
+func (s *Server) serveDSFRuleset(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	svc, ok := s.lookupService(w, args)
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 {
+		switch r.Method {
+		case "GET":
+			rulesets := []api.DSFRuleset{}
+			for _, rs := range svc.rulesets {
+				rulesets = append(rulesets, svc.renderRuleset(rs))
+			}
+			writeSuccess(w, rulesets)
+		case "POST":
+			var request api.DSFRulesetRequest
+			if !decode(w, body, &request) {
+				return
+			}
+			rs := &rulesetState{
+				ruleset: api.DSFRuleset{
+					ID:           s.newDSFID(),
+					Label:        request.Label,
+					CriteriaType: request.CriteriaType,
+					Eligible:     "true",
+				},
+			}
+			if request.Criteria != nil {
+				rs.ruleset.Criteria = *request.Criteria
+			}
+			if request.ResponsePool != nil {
+				rs.poolIDs = poolIDs(*request.ResponsePool)
+			}
+			svc.rulesets = append(svc.rulesets, rs)
+			svc.moveRuleset(len(svc.rulesets)-1, request.Ordering)
+			svc.track(request.PublishBlock)
+			writeSuccess(w, svc.renderRuleset(rs))
+		default:
+			writeMethodError(w, r)
+		}
+		return
+	}
+
+	index := -1
+	for i, rs := range svc.rulesets {
+		if rs.ruleset.ID == args[1] {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeNotFound(w, "ruleset")
+		return
+	}
+	rs := svc.rulesets[index]
+
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.renderRuleset(rs))
+	case "PUT":
+		var request api.DSFRulesetRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		if request.Label != "" {
+			rs.ruleset.Label = request.Label
+		}
+		if request.CriteriaType != "" {
+			rs.ruleset.CriteriaType = request.CriteriaType
+			rs.ruleset.Criteria = api.DSFCriteria{}
+			if request.Criteria != nil {
+				rs.ruleset.Criteria = *request.Criteria
+			}
+		}
+		if request.ResponsePool != nil {
+			rs.poolIDs = poolIDs(*request.ResponsePool)
+		}
+		svc.moveRuleset(index, request.Ordering)
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderRuleset(rs))
+	case "DELETE":
+		var request api.PublishBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		svc.rulesets = append(svc.rulesets[:index], svc.rulesets[index+1:]...)
+		svc.renumberRulesets()
+		svc.track(request)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+// moveRuleset moves the ruleset at index to the position given by ordering,
+// clamped to the list, or leaves it in place when ordering is empty.
+func (svc *serviceState) moveRuleset(index int, ordering string) {
+	if position, err := strconv.Atoi(ordering); err == nil {
+		if position < 0 {
+			position = 0
+		}
+		if position >= len(svc.rulesets) {
+			position = len(svc.rulesets) - 1
+		}
+		rs := svc.rulesets[index]
+		svc.rulesets = append(svc.rulesets[:index], svc.rulesets[index+1:]...)
+		svc.rulesets = append(svc.rulesets[:position], append([]*rulesetState{rs}, svc.rulesets[position:]...)...)
+	}
+	svc.renumberRulesets()
+}
+
+func (svc *serviceState) renumberRulesets() {
+	for i, rs := range svc.rulesets {
+		rs.ruleset.Ordering = fmt.Sprintf("%d", i)
+	}
+}
+
+func poolIDs(refs []api.DSFResponsePoolRef) []string {
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = ref.ID
+	}
+	return ids
+}
+
+func (s *Server) serveDSFResponsePool(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	svc, ok := s.lookupService(w, args)
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 {
+		switch r.Method {
+		case "GET":
+			pools := []api.DSFResponsePool{}
+			for _, id := range sortedKeys(svc.pools) {
+				pools = append(pools, svc.renderPool(svc.pools[id]))
+			}
+			writeSuccess(w, pools)
+		case "POST":
+			var request api.DSFResponsePoolRequest
+			if !decode(w, body, &request) {
+				return
+			}
+			pool := &api.DSFResponsePool{
+				ID:         s.newDSFID(),
+				Label:      request.Label,
+				Automation: defaultString(request.Automation, "auto"),
+				Eligible:   "true",
+				Status:     "ok",
+			}
+			svc.pools[pool.ID] = pool
+			svc.track(request.PublishBlock)
+			writeSuccess(w, svc.renderPool(pool))
+		default:
+			writeMethodError(w, r)
+		}
+		return
+	}
+
+	pool, ok := svc.pools[args[1]]
+	if !ok {
+		writeNotFound(w, "response pool")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.renderPool(pool))
+	case "PUT":
+		var request api.DSFResponsePoolRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		if request.Label != "" {
+			pool.Label = request.Label
+		}
+		if request.Automation != "" {
+			pool.Automation = request.Automation
+		}
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderPool(pool))
+	case "DELETE":
+		var request api.PublishBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		delete(svc.pools, pool.ID)
+		for _, rs := range svc.rulesets {
+			ids := rs.poolIDs[:0]
+			for _, id := range rs.poolIDs {
+				if id != pool.ID {
+					ids = append(ids, id)
+				}
+			}
+			rs.poolIDs = ids
+		}
+		svc.track(request)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func (s *Server) serveDSFRsfc(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	svc, ok := s.lookupService(w, args)
+	if !ok {
+		return
+	}
+	if len(args) == 1 && r.Method == "GET" {
+		chains := []api.DSFRecordSetChain{}
+		for _, chain := range svc.sortedChains() {
+			chains = append(chains, svc.renderChain(chain))
+		}
+		writeSuccess(w, chains)
+		return
+	}
+	if len(args) != 2 {
+		writeNotFound(w, "record set failover chain")
+		return
+	}
+
+	if r.Method == "POST" {
+		pool, ok := svc.pools[args[1]]
+		if !ok {
+			writeNotFound(w, "response pool")
+			return
+		}
+		var request api.DSFRsfcRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		chain := &api.DSFRecordSetChain{
+			ID:                s.newDSFID(),
+			Status:            "ok",
+			Core:              "false",
+			Label:             request.Label,
+			DSFResponsePoolID: pool.ID,
+			DSFServiceID:      svc.service.ID,
+		}
+		svc.chains[chain.ID] = chain
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderChain(chain))
+		return
+	}
+
+	chain, ok := svc.chains[args[1]]
+	if !ok {
+		writeNotFound(w, "record set failover chain")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.renderChain(chain))
+	case "PUT":
+		var request api.DSFRsfcRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		if request.Label != "" {
+			chain.Label = request.Label
+		}
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderChain(chain))
+	case "DELETE":
+		var request api.PublishBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		delete(svc.chains, chain.ID)
+		svc.track(request)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func (s *Server) serveDSFRecordSet(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	svc, ok := s.lookupService(w, args)
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 {
+		if r.Method != "POST" {
+			writeMethodError(w, r)
+			return
+		}
+		var request api.DSFRecordSetRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		if _, ok := svc.pools[request.ResponsePoolId]; !ok {
+			writeNotFound(w, "response pool")
+			return
+		}
+		if _, ok := svc.chains[request.DSFRsfc]; !ok {
+			writeNotFound(w, "record set failover chain")
+			return
+		}
+		set := &recordSetState{
+			recordSet: api.DSFRecordSet{
+				Status:     "ok",
+				Eligible:   true,
+				ID:         s.newDSFID(),
+				ServiceID:  svc.service.ID,
+				RDataClass: request.RDataClass,
+				Automation: "auto",
+				ServeCount: 1,
+			},
+			chainID: request.DSFRsfc,
+			poolID:  request.ResponsePoolId,
+		}
+		set.update(&request)
+		svc.recordSets[set.recordSet.ID] = set
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderRecordSet(set))
+		return
+	}
+
+	set, ok := svc.recordSets[args[1]]
+	if !ok {
+		writeNotFound(w, "record set")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, svc.renderRecordSet(set))
+	case "PUT":
+		var request api.DSFRecordSetRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		set.update(&request)
+		svc.track(request.PublishBlock)
+		writeSuccess(w, svc.renderRecordSet(set))
+	case "DELETE":
+		var request api.PublishBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		delete(svc.recordSets, set.recordSet.ID)
+		svc.track(request)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func (rs *recordSetState) update(request *api.DSFRecordSetRequest) {
+	set := &rs.recordSet
+	set.Label = request.Label
+	if request.TTL != 0 {
+		set.TTL = request.TTL
+	}
+	if request.Automation != "" {
+		set.Automation = request.Automation
+	}
+	if request.ServeCount != 0 {
+		set.ServeCount = request.ServeCount
+	}
+	if request.FailCount != 0 {
+		set.FailCount = request.FailCount
+	}
+	if request.TroubleCount != 0 {
+		set.TroubleCount = request.TroubleCount
+	}
+	if request.Eligible != nil {
+		set.Eligible = *request.Eligible
+	}
+	set.MonitorID = ""
+	if request.MonitorID != nil {
+		set.MonitorID = *request.MonitorID
+	}
+}
+
+func (s *Server) serveDSFRecord(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	svc, ok := s.lookupService(w, args)
+	if !ok {
+		return
+	}
+	if len(args) != 2 {
+		writeNotFound(w, "record")
+		return
+	}
+
+	if r.Method == "POST" {
+		if _, ok := svc.recordSets[args[1]]; !ok {
+			writeNotFound(w, "record set")
+			return
+		}
+		var request api.DSFRecordRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		record := &api.DSFRecord{
+			Status:         "ok",
+			Endpoints:      []string{},
+			Eligible:       true,
+			ID:             s.newDSFID(),
+			DSFRecordSetID: args[1],
+			DSFServiceID:   svc.service.ID,
+			Automation:     "auto",
+			Weight:         1,
+		}
+		updateDSFRecord(record, &request)
+		svc.records[record.ID] = record
+		svc.track(request.PublishBlock)
+		writeSuccess(w, record)
+		return
+	}
+
+	record, ok := svc.records[args[1]]
+	if !ok {
+		writeNotFound(w, "record")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, record)
+	case "PUT":
+		var request api.DSFRecordRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		updateDSFRecord(record, &request)
+		svc.track(request.PublishBlock)
+		writeSuccess(w, record)
+	case "DELETE":
+		var request api.PublishBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		delete(svc.records, record.ID)
+		svc.track(request)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func updateDSFRecord(record *api.DSFRecord, request *api.DSFRecordRequest) {
+	record.Label = request.Label
+	if request.Weight != 0 {
+		record.Weight = request.Weight
+	}
+	if request.Automation != "" {
+		record.Automation = request.Automation
+	}
+	if request.Eligible != nil {
+		record.Eligible = *request.Eligible
+	}
+	if request.MasterLine != "" {
+		record.MasterLine = request.MasterLine
+	}
+}
+
+func (s *Server) serveDSFMonitor(w http.ResponseWriter, r *http.Request, args []string, body []byte) {
+	if len(args) == 0 {
+		switch r.Method {
+		case "GET":
+			monitors := []api.DSFMonitor{}
+			for _, id := range sortedKeys(s.monitors) {
+				monitors = append(monitors, *s.monitors[id])
+			}
+			writeSuccess(w, monitors)
+		case "POST":
+			var monitor api.DSFMonitor
+			if !decode(w, body, &monitor) {
+				return
+			}
+			monitor.ID = s.newDSFID()
+			s.monitors[monitor.ID] = &monitor
+			writeSuccess(w, monitor)
+		default:
+			writeMethodError(w, r)
+		}
+		return
+	}
+
+	monitor, ok := s.monitors[args[0]]
+	if !ok {
+		writeNotFound(w, "monitor")
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, monitor)
+	case "PUT":
+		var request api.DSFMonitor
+		if !decode(w, body, &request) {
+			return
+		}
+		request.ID = monitor.ID
+		*monitor = request
+		writeSuccess(w, monitor)
+	case "DELETE":
+		delete(s.monitors, monitor.ID)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,362 @@
+// Package dyntest provides an in-memory emulation of the DynECT REST API, so
+// that the api package and the Terraform resources built on top of it can be
+// tested without credentials or a live zone.
+//
+// A Server models sessions, zones with unpublished (per session) and published
+// records, Traffic Director (DSF) services and monitors. It can also be asked
+// to answer with 429 rate limiting errors or to promote requests to jobs, in
+// order to exercise the client's error handling.
+package dyntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+)
+
+// Credentials accepted by a new Server.
+const (
+	Customer = "dyntest"
+	Username = "dyntest-user"
+	Password = "dyntest-password"
+)
+
+// Server is an in-memory DynECT REST API served by an httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	// JobPolls is the number of times a promoted job answers "incomplete"
+	// before returning its result.
+	JobPolls int
+
+	mu        sync.Mutex
+	nextID    int
+	sessions  map[string]bool
+	zones     map[string]*zoneState
+	services  map[string]*serviceState
+	monitors  map[string]*api.DSFMonitor
+	jobs      map[int]*job
+	requests  []string
+	rateLimit int
+	retryWait time.Duration
+	promote   int
+}
+
+type job struct {
+	polls int
+	body  []byte
+}
+
+// envelope is the body of every response returned by the DynECT API.
+type envelope struct {
+	Status   string             `json:"status"`
+	Data     interface{}        `json:"data"`
+	JobID    int                `json:"job_id"`
+	Messages []api.MessageBlock `json:"msgs"`
+}
+
+// NewServer starts a new Server, which must be closed once done.
+func NewServer() *Server {
+	s := &Server{
+		JobPolls: 1,
+		sessions: map[string]bool{},
+		zones:    map[string]*zoneState{},
+		services: map[string]*serviceState{},
+		monitors: map[string]*api.DSFMonitor{},
+		jobs:     map[int]*job{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint returns the base URL to configure on an api.Client.
+func (s *Server) Endpoint() string {
+	return s.URL + "/REST"
+}
+
+// NewClient returns a client logged in to the server.
+func (s *Server) NewClient() (*api.ConvenientClient, error) {
+	client := api.NewConvenientClient(Customer)
+	client.SetEndpoint(s.Endpoint())
+	if err := client.Login(Username, Password); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// RateLimit makes the next n requests fail with a 429 status. When wait is
+// not zero, it is sent in the Retry-After header.
+func (s *Server) RateLimit(n int, wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = n
+	s.retryWait = wait
+}
+
+// PromoteToJob makes the next n requests answer with a 307 redirection to a
+// job, as the API does for long-running requests.
+func (s *Server) PromoteToJob(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.promote = n
+}
+
+// ExpireSessions invalidates every open session, as the API does after a
+// long inactivity. Pending zone changes of the sessions are lost.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token := range s.sessions {
+		delete(s.sessions, token)
+		for _, z := range s.zones {
+			delete(z.pending, token)
+		}
+	}
+}
+
+// Requests returns every request received so far, formatted as
+// "METHOD Resource/path", e.g. "PUT Zone/example.com".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// CountRequests returns how many requests were received for a method on
+// paths starting with prefix.
+func (s *Server) CountRequests(method, prefix string) int {
+	count := 0
+	for _, r := range s.Requests() {
+		if strings.HasPrefix(r, method+" "+prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/REST/") {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "path: not an API endpoint")
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/REST/"), "/")
+	parts := strings.Split(path, "/")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_DATA", "body: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+path)
+
+	if parts[0] == "Job" {
+		defer s.mu.Unlock()
+		s.serveJob(w, r, parts[1:])
+		return
+	}
+	if s.rateLimit > 0 {
+		s.rateLimit--
+		if s.retryWait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.retryWait/time.Second)))
+		}
+		s.mu.Unlock()
+		writeError(w, http.StatusTooManyRequests, "", "Too many requests")
+		return
+	}
+	if s.promote > 0 && parts[0] != "Session" {
+		s.promote--
+		rec := httptest.NewRecorder()
+		s.handle(rec, r, parts, body)
+		id := s.newID()
+		s.jobs[id] = &job{polls: s.JobPolls, body: rec.Body.Bytes()}
+		s.mu.Unlock()
+		w.Header().Set("Location", fmt.Sprintf("/REST/Job/%d", id))
+		writeJSON(w, http.StatusTemporaryRedirect, envelope{Status: "incomplete", JobID: id})
+		return
+	}
+
+	defer s.mu.Unlock()
+	s.handle(w, r, parts, body)
+}
+
+func (s *Server) serveJob(w http.ResponseWriter, r *http.Request, parts []string) {
+	if !s.sessions[r.Header.Get("Auth-Token")] {
+		writeSessionError(w)
+		return
+	}
+	id, _ := strconv.Atoi(strings.Join(parts, "/"))
+	j, ok := s.jobs[id]
+	if !ok || r.Method != "GET" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "job: No such job")
+		return
+	}
+	if j.polls > 0 {
+		j.polls--
+		writeJSON(w, http.StatusOK, envelope{Status: "incomplete", JobID: id, Data: map[string]string{}})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j.body)
+}
+
+// handle dispatches a request to the emulated resource. It must be called
+// with s.mu held.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, parts []string, body []byte) {
+	resource, args := parts[0], parts[1:]
+	if resource == "Session" {
+		s.serveSession(w, r, body)
+		return
+	}
+
+	token := r.Header.Get("Auth-Token")
+	if !s.sessions[token] {
+		writeSessionError(w)
+		return
+	}
+
+	switch {
+	case resource == "Zone":
+		s.serveZone(w, r, token, args, body)
+	case resource == "ZoneChanges":
+		s.serveZoneChanges(w, r, token, args)
+	case resource == "AllRecord":
+		s.serveAllRecord(w, r, token, args, body)
+	case resource == "DSF":
+		s.serveDSF(w, r, args, body)
+	case resource == "DSFNode":
+		s.serveDSFNode(w, r, args, body)
+	case resource == "DSFRuleset":
+		s.serveDSFRuleset(w, r, args, body)
+	case resource == "DSFResponsePool":
+		s.serveDSFResponsePool(w, r, args, body)
+	case resource == "DSFRecordSetFailoverChain":
+		s.serveDSFRsfc(w, r, args, body)
+	case resource == "DSFRecordSet":
+		s.serveDSFRecordSet(w, r, args, body)
+	case resource == "DSFRecord":
+		s.serveDSFRecord(w, r, args, body)
+	case resource == "DSFMonitor":
+		s.serveDSFMonitor(w, r, args, body)
+	case strings.HasSuffix(resource, "Record") && len(resource) > len("Record"):
+		s.serveRecord(w, r, token, strings.TrimSuffix(resource, "Record"), args, body)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "path: unknown resource "+resource)
+	}
+}
+
+func (s *Server) serveSession(w http.ResponseWriter, r *http.Request, body []byte) {
+	token := r.Header.Get("Auth-Token")
+
+	switch r.Method {
+	case "POST":
+		var login api.LoginBlock
+		if err := json.Unmarshal(body, &login); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_DATA", "login: "+err.Error())
+			return
+		}
+		if login.CustomerName != Customer || login.Username != Username || login.Password != Password {
+			writeError(w, http.StatusBadRequest, "INVALID_DATA", "login: Credentials you entered did not match those in our database. Please try again")
+			return
+		}
+		token = fmt.Sprintf("dyntest-session-%d", s.newID())
+		s.sessions[token] = true
+		writeSuccess(w, api.LoginDataBlock{Token: token, Version: "3.7.0"})
+	case "GET", "PUT":
+		if !s.sessions[token] {
+			writeSessionError(w)
+			return
+		}
+		writeSuccess(w, map[string]string{})
+	case "DELETE":
+		if !s.sessions[token] {
+			writeSessionError(w)
+			return
+		}
+		delete(s.sessions, token)
+		for _, z := range s.zones {
+			delete(z.pending, token)
+		}
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeSuccess(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, envelope{
+		Status: "success",
+		Data:   data,
+		Messages: []api.MessageBlock{
+			{Info: "dyntest: request processed", Source: "API-B", Level: "INFO"},
+		},
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code, info string) {
+	writeJSON(w, status, envelope{
+		Status: "failure",
+		Data:   map[string]string{},
+		Messages: []api.MessageBlock{
+			{Info: info, Source: "BLL", ErrorCode: code, Level: "ERROR"},
+		},
+	})
+}
+
+func writeSessionError(w http.ResponseWriter) {
+	writeError(w, http.StatusBadRequest, "INVALID_DATA", "login: Bad or expired credentials")
+}
+
+func writeMethodError(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "INVALID_REQUEST", "method: "+r.Method+" is not supported")
+}
+
+func writeNotFound(w http.ResponseWriter, what string) {
+	writeError(w, http.StatusNotFound, "NOT_FOUND", what+": No such object")
+}
+
+// decode unmarshals a request body, answering with an INVALID_DATA error
+// when it fails.
+func decode(w http.ResponseWriter, body []byte, v interface{}) bool {
+	if len(body) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_DATA", "body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a map indexed by strings, in order.
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+package dyntest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+)
+
+func newTestClient(t *testing.T, s *Server) *api.ConvenientClient {
+	client, err := s.NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return client
+}
+
+func TestServer_login(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	client := api.NewConvenientClient(Customer)
+	client.SetEndpoint(s.Endpoint())
+	if err := client.Login(Username, "wrong"); err == nil {
+		t.Fatal("expected login with a wrong password to fail")
+	}
+	if err := client.Login(Username, Password); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.Logout(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.Do("GET", "Session", nil, nil); err == nil {
+		t.Fatal("expected the session to be closed")
+	}
+}
+
+func TestServer_recordPublish(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+
+	writer := newTestClient(t, s)
+	reader := newTestClient(t, s)
+
+	record := &api.Record{
+		Zone:  "example.com",
+		Name:  "www",
+		Type:  "A",
+		TTL:   "300",
+		Value: "192.168.0.10",
+	}
+	if err := writer.CreateRecord(record); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The change is only visible to the session which made it
+	if err := writer.GetRecordID(record); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := reader.GetRecord(&api.Record{Zone: "example.com", FQDN: "www.example.com", Type: "A", ID: record.ID}); err == nil {
+		t.Fatal("expected the unpublished record to be hidden from other sessions")
+	}
+	if n := len(s.PublishedRecords("example.com")); n != 1 {
+		t.Fatalf("expected only the SOA record to be published, got %d records", n)
+	}
+
+	serial := s.Serial("example.com")
+	if err := writer.PublishZone("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if s.Serial("example.com") != serial+1 {
+		t.Fatalf("expected the serial to be incremented")
+	}
+
+	found := &api.Record{Zone: "example.com", FQDN: "www.example.com", Type: "A", ID: record.ID}
+	if err := reader.GetRecord(found); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if found.Value != "192.168.0.10" || found.TTL != "300" || found.Name != "www" {
+		t.Fatalf("unexpected record: %#v", found)
+	}
+}
+
+func TestServer_zoneChanges(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+
+	writer := newTestClient(t, s)
+	reader := newTestClient(t, s)
+
+	record := &api.Record{Zone: "example.com", Name: "www", Type: "A", Value: "192.168.0.10"}
+	if err := writer.CreateRecord(record); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	changes, err := writer.GetZoneChanges("example.com")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(changes) != 1 || changes[0].String() != "A www.example.com 192.168.0.10" {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	if changes, err := reader.GetZoneChanges("example.com"); err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes in another session, got %v (err: %v)", changes, err)
+	}
+
+	if err := writer.DiscardZoneChanges("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	serial := s.Serial("example.com")
+	if err := writer.PublishZone("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if s.Serial("example.com") != serial {
+		t.Fatal("expected the discarded change not to be published")
+	}
+}
+
+func TestServer_job(t *testing.T) {
+	defer func(interval time.Duration) { api.PollingInterval = interval }(api.PollingInterval)
+	api.PollingInterval = time.Millisecond
+
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+	client := newTestClient(t, s)
+
+	s.JobPolls = 2
+	s.PromoteToJob(1)
+
+	var response api.ZoneResponse
+	if err := client.Do("GET", "Zone/example.com", nil, &response); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if response.Data.Zone != "example.com" {
+		t.Fatalf("unexpected job response: %#v", response)
+	}
+	if n := s.CountRequests("GET", "Job/"); n != 3 {
+		t.Fatalf("expected the job to be polled 3 times, got %d", n)
+	}
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+	client := newTestClient(t, s)
+	client.Retry = api.RetryPolicy{MaxRetries: 2, MinWait: time.Millisecond}
+
+	s.RateLimit(2, 0)
+	if err := client.PublishZone("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := s.CountRequests("PUT", "Zone/example.com"); n != 3 {
+		t.Fatalf("expected the publish to be sent 3 times, got %d", n)
+	}
+
+	s.RateLimit(3, 0)
+	if err := client.PublishZone("example.com"); !api.IsRateLimited(err) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	client.Retry = api.RetryPolicy{MaxRetries: 2, MinWait: time.Second, MaxElapsed: time.Millisecond}
+	s.RateLimit(1, 0)
+	if err := client.PublishZone("example.com"); !api.IsRateLimited(err) {
+		t.Fatalf("expected the elapsed budget to stop retries, got %v", err)
+	}
+}
+
+func TestServer_jobCancel(t *testing.T) {
+	defer func(interval time.Duration) { api.PollingInterval = interval }(api.PollingInterval)
+	api.PollingInterval = time.Millisecond
+
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+	client := newTestClient(t, s)
+
+	s.JobPolls = 1000000
+	s.PromoteToJob(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.PublishZoneContext(ctx, "example.com"); err != context.DeadlineExceeded {
+		t.Fatalf("expected the job polling to stop at the deadline, got %v", err)
+	}
+}
+
+func TestServer_sessionExpiry(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.AddZone("example.com")
+	client := newTestClient(t, s)
+
+	// The changes staged in the expired session are lost, so the publish is
+	// not replayed in a new one
+	s.ExpireSessions()
+	if err := client.PublishZone("example.com"); !api.IsStagedChangesLost(err) {
+		t.Fatalf("expected the lost changes to be reported, got %v", err)
+	}
+	if n := s.CountRequests("POST", "Session"); n != 1 {
+		t.Fatalf("expected the client not to log in again, got %d logins", n)
+	}
+
+	if err := client.KeepAlive(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := s.CountRequests("POST", "Session"); n != 2 {
+		t.Fatalf("expected the keep-alive to log in again, got %d logins", n)
+	}
+	if err := client.PublishZone("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.Logout(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.PublishZone("example.com"); err == nil {
+		t.Fatal("expected a closed client not to log in again")
+	}
+}
@@ -0,0 +1,468 @@
+package dyntest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+)
+
+// DefaultTTL is the TTL given to records created without one.
+const DefaultTTL = 3600
+
+type zoneState struct {
+	name        string
+	serial      int
+	serialStyle string
+	records     map[int]api.BaseRecord
+	// Changes made by each session, applied by a publish from that
+	// session.
+	pending map[string][]change
+	// Publishes fail while set, see FailPublish.
+	failPublish bool
+	// Notes sent with each publish
+	notes []string
+}
+
+type change struct {
+	op     string
+	record api.BaseRecord
+}
+
+// AddZone creates a published primary zone, with its SOA record.
+func (s *Server) AddZone(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addZone(name, "hostmaster@"+name, DefaultTTL, "increment")
+}
+
+func (s *Server) addZone(name, rname string, ttl int, serialStyle string) {
+	z := &zoneState{
+		name:        name,
+		serial:      1,
+		serialStyle: serialStyle,
+		records:     map[int]api.BaseRecord{},
+		pending:     map[string][]change{},
+	}
+	id := s.newID()
+	z.records[id] = api.BaseRecord{
+		FQDN:       name,
+		RecordId:   id,
+		RecordType: "SOA",
+		TTL:        ttl,
+		Zone:       name,
+		RData:      api.DataBlock{RName: rname},
+	}
+	s.zones[name] = z
+}
+
+// PublishedRecords returns the published records of a zone, sorted by ID.
+func (s *Server) PublishedRecords(zone string) []api.BaseRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	z, ok := s.zones[zone]
+	if !ok {
+		return nil
+	}
+	return sortedRecords(z.records)
+}
+
+// PublishNotes returns the notes sent with each publish of pending changes to
+// a zone.
+func (s *Server) PublishNotes(zone string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if z, ok := s.zones[zone]; ok {
+		return append([]string(nil), z.notes...)
+	}
+	return nil
+}
+
+// Serial returns the serial of a zone, which is incremented by every publish
+// of pending changes.
+func (s *Server) Serial(zone string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if z, ok := s.zones[zone]; ok {
+		return z.serial
+	}
+	return 0
+}
+
+// FailPublish makes the publishes of a zone fail, as when it is frozen,
+// until it is called again with fail false.
+func (s *Server) FailPublish(zone string, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if z, ok := s.zones[zone]; ok {
+		z.failPublish = fail
+	}
+}
+
+// view returns the records of the zone as seen by a session: the published
+// records with the session's pending changes applied.
+func (z *zoneState) view(token string) map[int]api.BaseRecord {
+	records := make(map[int]api.BaseRecord, len(z.records))
+	for id, r := range z.records {
+		records[id] = r
+	}
+	for _, c := range z.pending[token] {
+		switch c.op {
+		case "delete":
+			delete(records, c.record.RecordId)
+		default:
+			records[c.record.RecordId] = c.record
+		}
+	}
+	return records
+}
+
+func (z *zoneState) publish(token, notes string) {
+	if len(z.pending[token]) == 0 {
+		return
+	}
+	z.records = z.view(token)
+	delete(z.pending, token)
+	z.serial++
+	z.notes = append(z.notes, notes)
+}
+
+func sortedRecords(records map[int]api.BaseRecord) []api.BaseRecord {
+	result := make([]api.BaseRecord, 0, len(records))
+	for _, r := range records {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RecordId < result[j].RecordId })
+	return result
+}
+
+func recordURI(r api.BaseRecord) string {
+	return fmt.Sprintf("/REST/%sRecord/%s/%s/%d", r.RecordType, r.Zone, r.FQDN, r.RecordId)
+}
+
+func (s *Server) serveZone(w http.ResponseWriter, r *http.Request, token string, args []string, body []byte) {
+	if len(args) == 0 {
+		if r.Method != "GET" {
+			writeMethodError(w, r)
+			return
+		}
+		uris := []string{}
+		for _, name := range sortedKeys(s.zones) {
+			uris = append(uris, "/REST/Zone/"+name+"/")
+		}
+		writeSuccess(w, uris)
+		return
+	}
+	if len(args) != 1 {
+		writeMethodError(w, r)
+		return
+	}
+	if r.Method == "POST" {
+		s.createZone(w, args[0], body)
+		return
+	}
+	z, ok := s.zones[args[0]]
+	if !ok {
+		writeNotFound(w, "zone")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, z.data())
+	case "PUT":
+		var request api.PublishZoneBlock
+		if !decode(w, body, &request) {
+			return
+		}
+		if request.Publish {
+			if z.failPublish {
+				writeError(w, http.StatusBadRequest, api.ErrCodeOperationFail, "zone: Zone is frozen")
+				return
+			}
+			z.publish(token, request.Notes)
+		}
+		writeSuccess(w, z.data())
+	case "DELETE":
+		delete(s.zones, z.name)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func (s *Server) serveZoneChanges(w http.ResponseWriter, r *http.Request, token string, args []string) {
+	if len(args) != 1 {
+		writeMethodError(w, r)
+		return
+	}
+	z, ok := s.zones[args[0]]
+	if !ok {
+		writeNotFound(w, "zone")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		changes := []api.ZoneChange{}
+		for _, c := range z.pending[token] {
+			changes = append(changes, api.ZoneChange{
+				ID:        c.record.RecordId,
+				Zone:      z.name,
+				FQDN:      c.record.FQDN,
+				Serial:    z.serial,
+				TTL:       c.record.TTL,
+				RDataType: c.record.RecordType,
+				RData:     c.record.RData,
+			})
+		}
+		writeSuccess(w, changes)
+	case "DELETE":
+		delete(z.pending, token)
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+func (s *Server) createZone(w http.ResponseWriter, name string, body []byte) {
+	if _, ok := s.zones[name]; ok {
+		writeError(w, http.StatusBadRequest, "TARGET_EXISTS", "name: Name already exists")
+		return
+	}
+	var request api.CreateZoneBlock
+	if !decode(w, body, &request) {
+		return
+	}
+	if request.RName == "" {
+		writeError(w, http.StatusBadRequest, "MISSING_DATA", "rname: Value is required")
+		return
+	}
+	if request.TTL == 0 {
+		writeError(w, http.StatusBadRequest, "MISSING_DATA", "ttl: Value is required")
+		return
+	}
+	if request.SerialStyle == "" {
+		request.SerialStyle = "increment"
+	}
+	if !validSerialStyle(w, request.SerialStyle) {
+		return
+	}
+	s.addZone(name, request.RName, request.TTL, request.SerialStyle)
+	writeSuccess(w, s.zones[name].data())
+}
+
+func validSerialStyle(w http.ResponseWriter, style string) bool {
+	switch style {
+	case "increment", "epoch", "day", "minute":
+		return true
+	}
+	writeError(w, http.StatusBadRequest, "INVALID_DATA", "serial_style: Invalid serial style")
+	return false
+}
+
+func (z *zoneState) data() api.ZoneDataBlock {
+	return api.ZoneDataBlock{
+		Serial:      z.serial,
+		SerialStyle: z.serialStyle,
+		Zone:        z.name,
+		ZoneType:    "Primary",
+	}
+}
+
+func (s *Server) serveAllRecord(w http.ResponseWriter, r *http.Request, token string, args []string, body []byte) {
+	if r.Method != "GET" {
+		writeMethodError(w, r)
+		return
+	}
+	if len(args) < 1 || len(args) > 2 {
+		writeNotFound(w, "zone")
+		return
+	}
+	z, ok := s.zones[args[0]]
+	if !ok {
+		writeNotFound(w, "zone")
+		return
+	}
+	var request struct {
+		Detail string `json:"detail"`
+	}
+	if !decode(w, body, &request) {
+		return
+	}
+
+	uris := []string{}
+	detail := map[string][]api.BaseRecord{}
+	for _, record := range sortedRecords(z.view(token)) {
+		if len(args) == 2 && record.FQDN != args[1] {
+			continue
+		}
+		uris = append(uris, recordURI(record))
+		key := strings.ToLower(record.RecordType) + "_records"
+		detail[key] = append(detail[key], record)
+	}
+	if request.Detail == "Y" {
+		writeSuccess(w, detail)
+		return
+	}
+	writeSuccess(w, uris)
+}
+
+func (s *Server) serveRecord(w http.ResponseWriter, r *http.Request, token, recordType string, args []string, body []byte) {
+	if len(args) < 2 || len(args) > 3 {
+		writeNotFound(w, "node")
+		return
+	}
+	z, ok := s.zones[args[0]]
+	if !ok {
+		writeNotFound(w, "zone")
+		return
+	}
+	fqdn := args[1]
+	if fqdn != z.name && !strings.HasSuffix(fqdn, "."+z.name) {
+		writeError(w, http.StatusBadRequest, "INVALID_DATA", "fqdn: Not in zone")
+		return
+	}
+	records := z.view(token)
+
+	if len(args) == 2 {
+		switch r.Method {
+		case "GET":
+			uris := []string{}
+			for _, record := range sortedRecords(records) {
+				if record.FQDN == fqdn && record.RecordType == recordType {
+					uris = append(uris, recordURI(record))
+				}
+			}
+			writeSuccess(w, uris)
+		case "POST":
+			var request api.RecordRequest
+			if !decode(w, body, &request) {
+				return
+			}
+			ttl, ok := parseTTL(w, request.TTL, DefaultTTL)
+			if !ok {
+				return
+			}
+			record := api.BaseRecord{
+				FQDN:       fqdn,
+				RecordId:   s.newID(),
+				RecordType: recordType,
+				TTL:        ttl,
+				Zone:       z.name,
+				RData:      qualify(request.RData),
+			}
+			z.pending[token] = append(z.pending[token], change{op: "add", record: record})
+			writeSuccess(w, record)
+		case "PUT":
+			// Replace all the records of the type at the node
+			var request map[string][]api.RecordRequest
+			if !decode(w, body, &request) {
+				return
+			}
+			created := []api.BaseRecord{}
+			for _, rr := range request[recordType+"Records"] {
+				ttl, ok := parseTTL(w, rr.TTL, DefaultTTL)
+				if !ok {
+					return
+				}
+				created = append(created, api.BaseRecord{
+					FQDN:       fqdn,
+					RecordId:   s.newID(),
+					RecordType: recordType,
+					TTL:        ttl,
+					Zone:       z.name,
+					RData:      qualify(rr.RData),
+				})
+			}
+			for _, record := range sortedRecords(records) {
+				if record.FQDN == fqdn && record.RecordType == recordType {
+					z.pending[token] = append(z.pending[token], change{op: "delete", record: record})
+				}
+			}
+			for _, record := range created {
+				z.pending[token] = append(z.pending[token], change{op: "add", record: record})
+			}
+			writeSuccess(w, created)
+		case "DELETE":
+			for _, record := range sortedRecords(records) {
+				if record.FQDN == fqdn && record.RecordType == recordType {
+					z.pending[token] = append(z.pending[token], change{op: "delete", record: record})
+				}
+			}
+			writeSuccess(w, map[string]string{})
+		default:
+			writeMethodError(w, r)
+		}
+		return
+	}
+
+	id, _ := strconv.Atoi(args[2])
+	record, ok := records[id]
+	if !ok || record.FQDN != fqdn || record.RecordType != recordType {
+		writeNotFound(w, "record")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeSuccess(w, record)
+	case "PUT":
+		var request api.RecordRequest
+		if !decode(w, body, &request) {
+			return
+		}
+		ttl, ok := parseTTL(w, request.TTL, record.TTL)
+		if !ok {
+			return
+		}
+		if recordType == "SOA" {
+			// The serial style of the zone is set through its SOA
+			var soa api.UpdateSOABlock
+			if !decode(w, body, &soa) {
+				return
+			}
+			if soa.SerialStyle != "" {
+				if !validSerialStyle(w, soa.SerialStyle) {
+					return
+				}
+				z.serialStyle = soa.SerialStyle
+			}
+		}
+		record.TTL = ttl
+		record.RData = qualify(request.RData)
+		z.pending[token] = append(z.pending[token], change{op: "update", record: record})
+		writeSuccess(w, record)
+	case "DELETE":
+		z.pending[token] = append(z.pending[token], change{op: "delete", record: record})
+		writeSuccess(w, map[string]string{})
+	default:
+		writeMethodError(w, r)
+	}
+}
+
+// qualify adds the trailing dot to the domain names of record data, as the
+// API returns them.
+func qualify(rdata api.DataBlock) api.DataBlock {
+	for _, name := range []*string{&rdata.Alias, &rdata.CName, &rdata.DName, &rdata.Exchange, &rdata.NSDName, &rdata.PTRDname, &rdata.Target} {
+		if *name != "" && !strings.HasSuffix(*name, ".") {
+			*name += "."
+		}
+	}
+	return rdata
+}
+
+func parseTTL(w http.ResponseWriter, value string, fallback int) (int, bool) {
+	if value == "" || value == "0" {
+		return fallback, true
+	}
+	ttl, err := strconv.Atoi(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_DATA", "ttl: Not a number")
+		return 0, false
+	}
+	return ttl, true
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error codes found in the ERR_CD field of the messages returned by the
+// DynECT API.
+const (
+	ErrCodeNotFound       = "NOT_FOUND"
+	ErrCodeTargetExists   = "TARGET_EXISTS"
+	ErrCodeInvalidData    = "INVALID_DATA"
+	ErrCodeInvalidRequest = "INVALID_REQUEST"
+	ErrCodeOperationFail  = "OPERATION_FAILED"
+)
+
+// Error is returned by Client.Do when the DynECT API rejected a request, or
+// when the job it was promoted to failed.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Status is the status reported in the response body, usually
+	// "failure".
+	Status string
+	// JobID is the ID of the job which processed the request, if any.
+	JobID int
+	// Messages are the messages of the response body.
+	Messages []MessageBlock
+
+	// body is the raw response body, reported when it could not be parsed.
+	body string
+}
+
+// newError builds an Error from a failed response.
+func newError(statusCode int, body []byte) *Error {
+	e := &Error{StatusCode: statusCode}
+	var response ResponseBlock
+	if err := json.Unmarshal(body, &response); err != nil {
+		e.body = string(body)
+		return e
+	}
+	e.Status = response.Status
+	e.JobID = response.JobId
+	e.Messages = response.Messages
+	return e
+}
+
+func (e *Error) Error() string {
+	var msgs []string
+	for _, msg := range e.Messages {
+		if msg.Level != "ERROR" && msg.ErrorCode == "" {
+			continue
+		}
+		if msg.ErrorCode != "" {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", msg.ErrorCode, msg.Info))
+		} else {
+			msgs = append(msgs, msg.Info)
+		}
+	}
+	if len(msgs) == 0 && e.body != "" {
+		msgs = append(msgs, e.body)
+	}
+
+	var prefix string
+	if e.JobID != 0 && e.StatusCode == http.StatusOK {
+		prefix = fmt.Sprintf("job %d failed", e.JobID)
+	} else {
+		prefix = fmt.Sprintf("server responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(msgs) == 0 {
+		return prefix
+	}
+	return prefix + ": " + strings.Join(msgs, "; ")
+}
+
+// Is makes errors.Is(err, ErrRateLimited) report rate limiting errors.
+func (e *Error) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// HasErrorCode reports whether one of the messages has the given ERR_CD.
+func (e *Error) HasErrorCode(code string) bool {
+	for _, msg := range e.Messages {
+		if msg.ErrorCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err means that the requested object does not
+// exist.
+func IsNotFound(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.StatusCode == http.StatusNotFound || e.HasErrorCode(ErrCodeNotFound)
+}
+
+// IsRateLimited reports whether err means that the request was still rate
+// limited once the retry policy was exhausted.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsStagedChangesLost reports whether err means that the changes staged in
+// an expired session were lost before being published.
+func IsStagedChangesLost(err error) bool {
+	return errors.Is(err, ErrStagedChangesLost)
+}
+
+// IsInvalidData reports whether err means that the API rejected the data of
+// the request.
+func IsInvalidData(err error) bool {
+	return hasErrorCode(err, ErrCodeInvalidData)
+}
+
+// IsTargetExists reports whether err means that the object to create already
+// exists.
+func IsTargetExists(err error) bool {
+	return hasErrorCode(err, ErrCodeTargetExists)
+}
+
+func hasErrorCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.HasErrorCode(code)
+}
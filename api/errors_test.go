@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	cases := []struct {
+		status       int
+		body         string
+		message      string
+		notFound     bool
+		rateLimited  bool
+		invalidData  bool
+		targetExists bool
+	}{
+		{
+			404, `{"status": "failure", "data": {}, "job_id": 12, "msgs": [{"INFO": "node: Not in zone", "SOURCE": "BLL", "ERR_CD": "NOT_FOUND", "LVL": "ERROR"}]}`,
+			"server responded with 404 Not Found: NOT_FOUND: node: Not in zone",
+			true, false, false, false,
+		},
+		{
+			400, `{"status": "failure", "data": {}, "job_id": 13, "msgs": [{"INFO": "name: Name already exists", "SOURCE": "BLL", "ERR_CD": "TARGET_EXISTS", "LVL": "ERROR"}, {"INFO": "create: You already have this zone.", "SOURCE": "BLL", "ERR_CD": null, "LVL": "INFO"}]}`,
+			"server responded with 400 Bad Request: TARGET_EXISTS: name: Name already exists",
+			false, false, false, true,
+		},
+		{
+			400, `{"status": "failure", "data": {}, "job_id": 14, "msgs": [{"INFO": "ttl: Not a valid integer", "SOURCE": "BLL", "ERR_CD": "INVALID_DATA", "LVL": "ERROR"}]}`,
+			"server responded with 400 Bad Request: INVALID_DATA: ttl: Not a valid integer",
+			false, false, true, false,
+		},
+		{
+			429, `{"status": "failure", "data": {}, "job_id": 15, "msgs": [{"INFO": "Too many requests", "SOURCE": "BLL", "ERR_CD": null, "LVL": "ERROR"}]}`,
+			"server responded with 429 Too Many Requests: Too many requests",
+			false, true, false, false,
+		},
+		{
+			502, `<html>Bad Gateway</html>`,
+			"server responded with 502 Bad Gateway: <html>Bad Gateway</html>",
+			false, false, false, false,
+		},
+	}
+
+	for _, tc := range cases {
+		// Errors are usually wrapped by the resources
+		err := fmt.Errorf("Error reading: %w", newError(tc.status, []byte(tc.body)))
+
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected an *Error, got %T", err)
+		}
+		if apiErr.Error() != tc.message {
+			t.Errorf("expected message %q, got %q", tc.message, apiErr.Error())
+		}
+		if IsNotFound(err) != tc.notFound {
+			t.Errorf("%s: expected IsNotFound to be %v", tc.message, tc.notFound)
+		}
+		if IsRateLimited(err) != tc.rateLimited {
+			t.Errorf("%s: expected IsRateLimited to be %v", tc.message, tc.rateLimited)
+		}
+		if IsInvalidData(err) != tc.invalidData {
+			t.Errorf("%s: expected IsInvalidData to be %v", tc.message, tc.invalidData)
+		}
+		if IsTargetExists(err) != tc.targetExists {
+			t.Errorf("%s: expected IsTargetExists to be %v", tc.message, tc.targetExists)
+		}
+	}
+
+	jobErr := &Error{StatusCode: 200, Status: "failure", JobID: 16, Messages: []MessageBlock{
+		{Info: "publish: Zone not published", ErrorCode: "OPERATION_FAILED", Level: "ERROR"},
+	}}
+	if expected := "job 16 failed: OPERATION_FAILED: publish: Zone not published"; jobErr.Error() != expected {
+		t.Errorf("expected message %q, got %q", expected, jobErr.Error())
+	}
+}
@@ -32,6 +32,8 @@ type SInt int
 
 type PublishBlock struct {
 	Publish YNBool `json:"publish"`
+	// Notes are shown in the change history of the published service
+	Notes string `json:"notes,omitempty"`
 }
 
 // Type MessageBlock holds the message information from the server, and is
@@ -69,6 +71,8 @@ type RecordRequest struct {
 // https://help.dyn.com/update-zone-api/
 type PublishZoneBlock struct {
 	Publish bool `json:"publish"`
+	// Notes are shown in the change history of the zone
+	Notes string `json:"notes,omitempty"`
 }
 
 func (val *SInt) UnmarshalJSON(b []byte) error {
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlexString is a string which the DynECT API encodes as a JSON number when
+// it holds one, e.g. the port of an SRV record or the algorithm of a DS
+// record. It accepts both encodings when decoding.
+type FlexString string
+
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+func (s FlexString) MarshalJSON() ([]byte, error) {
+	if jsonNumber.MatchString(string(s)) {
+		return []byte(s), nil
+	}
+	return json.Marshal(string(s))
+}
+
+func (s *FlexString) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*s = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*s = FlexString(value)
+		return nil
+	}
+	var value json.Number
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = FlexString(value)
+	return nil
+}
+
+// rdataFieldKind tells how a field of a record is written in the value of a
+// record, which follows the zone file presentation format.
+type rdataFieldKind int
+
+const (
+	// A single word, e.g. a number or a domain name
+	rdataWord rdataFieldKind = iota
+	// A double quoted string, which may contain spaces
+	rdataQuoted
+	// A number of meters, suffixed with "m"
+	rdataMeters
+	// Base64 or hexadecimal data, written without spaces. Spaces are
+	// allowed in the value, but are removed.
+	rdataData
+	// The rest of the value, as is
+	rdataText
+)
+
+type rdataField struct {
+	kind rdataFieldKind
+	// Number of words of a rdataWord field, 1 by default
+	words int
+	// Trailing fields which may be left out
+	optional bool
+	// Fields which must hold an unsigned integer
+	number bool
+	// Pointer to the field of the DataBlock: *string or *FlexString
+	field func(*DataBlock) interface{}
+}
+
+type rdataFormat struct {
+	// Syntax of the value, for documentation and error messages
+	syntax string
+	fields []rdataField
+}
+
+func word(field func(*DataBlock) interface{}) rdataField {
+	return rdataField{kind: rdataWord, field: field}
+}
+
+func number(field func(*DataBlock) interface{}) rdataField {
+	return rdataField{kind: rdataWord, number: true, field: field}
+}
+
+func quoted(field func(*DataBlock) interface{}) rdataField {
+	return rdataField{kind: rdataQuoted, field: field}
+}
+
+func data(field func(*DataBlock) interface{}) rdataField {
+	return rdataField{kind: rdataData, field: field}
+}
+
+func text(field func(*DataBlock) interface{}) rdataField {
+	return rdataField{kind: rdataText, field: field}
+}
+
+// rdataFormats lists the record types supported by the DynECT API, with the
+// format of their value.
+var rdataFormats = map[string]rdataFormat{
+	"A": {"address", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Address }),
+	}},
+	"AAAA": {"address", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Address }),
+	}},
+	"ALIAS": {"alias", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Alias }),
+	}},
+	"CAA": {`flags tag "value"`, []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Flags }),
+		word(func(r *DataBlock) interface{} { return &r.Tag }),
+		quoted(func(r *DataBlock) interface{} { return &r.Value }),
+	}},
+	"CDNSKEY": dnskeyFormat,
+	"CDS":     dsFormat,
+	"CERT": {"format tag algorithm certificate", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Format }),
+		word(func(r *DataBlock) interface{} { return &r.Tag }),
+		word(func(r *DataBlock) interface{} { return &r.Algorithm }),
+		data(func(r *DataBlock) interface{} { return &r.Certificate }),
+	}},
+	"CNAME": {"cname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.CName }),
+	}},
+	"DHCID": {"digest", []rdataField{
+		data(func(r *DataBlock) interface{} { return &r.Digest }),
+	}},
+	"DNAME": {"dname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.DName }),
+	}},
+	"DNSKEY": dnskeyFormat,
+	"DS":     dsFormat,
+	"IPSECKEY": {"precedence gatetype algorithm gateway public_key", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Precendence }),
+		word(func(r *DataBlock) interface{} { return &r.GatewayType }),
+		word(func(r *DataBlock) interface{} { return &r.Algorithm }),
+		word(func(r *DataBlock) interface{} { return &r.Gateway }),
+		data(func(r *DataBlock) interface{} { return &r.PublicKey }),
+	}},
+	"KEY": dnskeyFormat,
+	"KX": {"preference exchange", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.Preference }),
+		word(func(r *DataBlock) interface{} { return &r.Exchange }),
+	}},
+	"LOC": {"d1 m1 s1 N|S d2 m2 s2 E|W altitude[m] [size[m] [horiz_pre[m] [vert_pre[m]]]]", []rdataField{
+		{kind: rdataWord, words: 4, field: func(r *DataBlock) interface{} { return &r.Latitude }},
+		{kind: rdataWord, words: 4, field: func(r *DataBlock) interface{} { return &r.Longitude }},
+		{kind: rdataMeters, field: func(r *DataBlock) interface{} { return &r.Altitude }},
+		{kind: rdataMeters, optional: true, field: func(r *DataBlock) interface{} { return &r.Size }},
+		{kind: rdataMeters, optional: true, field: func(r *DataBlock) interface{} { return &r.HorizPre }},
+		{kind: rdataMeters, optional: true, field: func(r *DataBlock) interface{} { return &r.VertPre }},
+	}},
+	"MX": {"preference exchange", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.Preference }),
+		word(func(r *DataBlock) interface{} { return &r.Exchange }),
+	}},
+	"NAPTR": {`order preference "flags" "services" "regexp" replacement`, []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Order }),
+		number(func(r *DataBlock) interface{} { return &r.Preference }),
+		quoted(func(r *DataBlock) interface{} { return &r.Flags }),
+		quoted(func(r *DataBlock) interface{} { return &r.Services }),
+		quoted(func(r *DataBlock) interface{} { return &r.Regexp }),
+		word(func(r *DataBlock) interface{} { return &r.Replacement }),
+	}},
+	"NS": {"nsdname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.NSDName }),
+	}},
+	"NSAP": {"nsap", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.NSAP }),
+	}},
+	"PTR": {"ptrdname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.PTRDname }),
+	}},
+	"PX": {"preference map822 mapx400", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.Preference }),
+		word(func(r *DataBlock) interface{} { return &r.Map822 }),
+		word(func(r *DataBlock) interface{} { return &r.MapX400 }),
+	}},
+	"RP": {"mbox txtdname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.Mbox }),
+		word(func(r *DataBlock) interface{} { return &r.TxtDName }),
+	}},
+	"SOA": {"rname", []rdataField{
+		word(func(r *DataBlock) interface{} { return &r.RName }),
+	}},
+	"SPF": {"txtdata", []rdataField{
+		text(func(r *DataBlock) interface{} { return &r.TxtData }),
+	}},
+	"SRV": {"priority weight port target", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.Priority }),
+		number(func(r *DataBlock) interface{} { return &r.Weight }),
+		number(func(r *DataBlock) interface{} { return &r.Port }),
+		word(func(r *DataBlock) interface{} { return &r.Target }),
+	}},
+	"SSHFP": {"algorithm fptype fingerprint", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.Algorithm }),
+		number(func(r *DataBlock) interface{} { return &r.FPType }),
+		data(func(r *DataBlock) interface{} { return &r.Fingerprint }),
+	}},
+	"TLSA": {"cert_usage selector match_type certificate", []rdataField{
+		number(func(r *DataBlock) interface{} { return &r.CertUsage }),
+		number(func(r *DataBlock) interface{} { return &r.Selector }),
+		number(func(r *DataBlock) interface{} { return &r.MatchType }),
+		data(func(r *DataBlock) interface{} { return &r.Certificate }),
+	}},
+	"TXT": {"txtdata", []rdataField{
+		text(func(r *DataBlock) interface{} { return &r.TxtData }),
+	}},
+}
+
+var dnskeyFormat = rdataFormat{"flags protocol algorithm public_key", []rdataField{
+	number(func(r *DataBlock) interface{} { return &r.Flags }),
+	number(func(r *DataBlock) interface{} { return &r.Protocol }),
+	number(func(r *DataBlock) interface{} { return &r.Algorithm }),
+	data(func(r *DataBlock) interface{} { return &r.PublicKey }),
+}}
+
+var dsFormat = rdataFormat{"keytag algorithm digtype digest", []rdataField{
+	number(func(r *DataBlock) interface{} { return &r.KeyTag }),
+	number(func(r *DataBlock) interface{} { return &r.Algorithm }),
+	number(func(r *DataBlock) interface{} { return &r.DigestType }),
+	data(func(r *DataBlock) interface{} { return &r.Digest }),
+}}
+
+// RecordTypes returns the record types supported by ParseRData and
+// FormatRData, in order.
+func RecordTypes() []string {
+	types := make([]string, 0, len(rdataFormats))
+	for t := range rdataFormats {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RDataSyntax returns the syntax of the value of a record type, e.g.
+// "priority weight port target" for SRV, or "" if the type is not supported.
+func RDataSyntax(recordType string) string {
+	return rdataFormats[recordType].syntax
+}
+
+// ParseRData parses the value of a record, written in the zone file
+// presentation format of its type without the owner, TTL, class and type,
+// e.g. "10 20 5060 sip.example.com." for an SRV record.
+//
+// The value of TXT and SPF records is their text as is, and the value of SOA
+// records is the responsible person (rname) only.
+func ParseRData(recordType, value string) (DataBlock, error) {
+	var rdata DataBlock
+
+	format, ok := rdataFormats[recordType]
+	if !ok {
+		return rdata, fmt.Errorf("Invalid Dyn record type: %s", recordType)
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid %s record value %q, expected %q: %s", recordType, value, format.syntax, reason)
+	}
+
+	if len(format.fields) == 1 && format.fields[0].kind == rdataText {
+		*format.fields[0].field(&rdata).(*string) = value
+		return rdata, nil
+	}
+
+	words, err := splitRData(value)
+	if err != nil {
+		return rdata, invalid(err.Error())
+	}
+	for i, f := range format.fields {
+		if len(words) == 0 {
+			if f.optional {
+				break
+			}
+			return rdata, invalid(fmt.Sprintf("missing field %d", i+1))
+		}
+
+		var s string
+		switch f.kind {
+		case rdataData:
+			s = strings.Join(words, "")
+			words = nil
+		case rdataMeters:
+			s = strings.TrimSuffix(words[0], "m")
+			words = words[1:]
+		default:
+			n := f.words
+			if n == 0 {
+				n = 1
+			}
+			if len(words) < n {
+				return rdata, invalid(fmt.Sprintf("missing field %d", i+1))
+			}
+			s = strings.Join(words[:n], " ")
+			words = words[n:]
+		}
+
+		if f.number {
+			if _, err := strconv.ParseUint(s, 10, 16); err != nil {
+				return rdata, invalid(fmt.Sprintf("field %d is not a number", i+1))
+			}
+		}
+		switch field := f.field(&rdata).(type) {
+		case *string:
+			*field = s
+		case *FlexString:
+			*field = FlexString(s)
+		}
+	}
+	if len(words) > 0 {
+		return rdata, invalid("too many fields")
+	}
+
+	return rdata, nil
+}
+
+// FormatRData formats the value of a record, as parsed by ParseRData.
+func FormatRData(recordType string, rdata DataBlock) (string, error) {
+	format, ok := rdataFormats[recordType]
+	if !ok {
+		return "", fmt.Errorf("Invalid Dyn record type: %s", recordType)
+	}
+
+	var words []string
+	for _, f := range format.fields {
+		var s string
+		switch field := f.field(&rdata).(type) {
+		case *string:
+			s = *field
+		case *FlexString:
+			s = string(*field)
+		}
+
+		if f.optional && s == "" {
+			break
+		}
+		switch f.kind {
+		case rdataQuoted:
+			s = quoteRData(s)
+		case rdataMeters:
+			s += "m"
+		}
+		words = append(words, s)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// splitRData splits a value in words separated by spaces, a double quoted
+// string being a single word. Quotes and backslashes are escaped with a
+// backslash in quoted strings.
+func splitRData(value string) ([]string, error) {
+	var words []string
+	for i := 0; i < len(value); {
+		switch c := value[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			if i == len(value) {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+			i++
+			words = append(words, b.String())
+		default:
+			j := strings.IndexAny(value[i:], " \t")
+			if j < 0 {
+				j = len(value) - i
+			}
+			words = append(words, value[i:i+j])
+			i += j
+		}
+	}
+	return words, nil
+}
+
+func quoteRData(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRDataRoundTrip(t *testing.T) {
+	cases := []struct {
+		recordType string
+		value      string
+	}{
+		{"A", "192.168.0.10"},
+		{"AAAA", "2001:db8::10"},
+		{"ALIAS", "lb.example.net."},
+		{"CAA", `0 issue "letsencrypt.org"`},
+		{"CAA", `128 iodef "mailto:security@example.com"`},
+		{"CDNSKEY", "257 3 13 mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ=="},
+		{"CDS", "2371 13 2 1F987CC6583E92DF0890718C42E3FBD3F7E3FDCDAE6D1EF5F2E3ADD5DE5A1A72"},
+		{"CERT", "1 12345 8 MIICWzCCAcSgAwIBAgIB"},
+		{"CNAME", "www.example.net."},
+		{"DHCID", "AAIBY2/AuCccgoJbsaxcQc9TUapptP69lOjxfNuVAA2kjEA="},
+		{"DNAME", "example.net."},
+		{"DNSKEY", "256 3 8 AwEAAaetidLzsKWUt4swWR8yu0wPHPiUi8LU"},
+		{"DS", "60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118"},
+		{"IPSECKEY", "10 1 2 192.0.2.38 AQNRU3mG7TVTO2BkR47usntb102uFJtugbo6BSGvgqt4AQ=="},
+		{"KEY", "256 3 8 AwEAAaetidLzsKWUt4swWR8yu0wPHPiUi8LU"},
+		{"KX", "10 kx.example.com."},
+		{"LOC", "42 21 54 N 71 6 18 W -24m 30m 10m 10m"},
+		{"MX", "10 mail.example.com."},
+		{"MX", "0 ."},
+		{"NAPTR", `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+		{"NS", "ns1.p01.dynect.net."},
+		{"NSAP", "0x47.0005.80.005a00.0000.0001.e133.aaaaaa000151.00"},
+		{"PTR", "host.example.com."},
+		{"PX", "10 example.com. px400.example.net."},
+		{"RP", "admin.example.com. info.example.com."},
+		{"SOA", "hostmaster@example.com"},
+		{"SPF", "v=spf1 include:_spf.example.com ~all"},
+		{"SRV", "10 20 5060 sip.example.com."},
+		{"SRV", "0 5 5060 sip.example.com."},
+		{"SSHFP", "1 1 123456789abcdef67890123456789abcdef67890"},
+		{"TLSA", "3 1 1 0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6"},
+		{"TXT", `v=DMARC1; p=none; rua="mailto:dmarc@example.com"`},
+	}
+
+	for _, tc := range cases {
+		rdata, err := ParseRData(tc.recordType, tc.value)
+		if err != nil {
+			t.Errorf("%s %q: %s", tc.recordType, tc.value, err)
+			continue
+		}
+
+		// Go through the API encoding too
+		js, err := json.Marshal(rdata)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		var decoded DataBlock
+		if err := json.Unmarshal(js, &decoded); err != nil {
+			t.Fatalf("%s %s: %s", tc.recordType, js, err)
+		}
+
+		value, err := FormatRData(tc.recordType, decoded)
+		if err != nil {
+			t.Errorf("%s %q: %s", tc.recordType, tc.value, err)
+			continue
+		}
+		if value != tc.value {
+			t.Errorf("%s: expected %q, got %q", tc.recordType, tc.value, value)
+		}
+	}
+}
+
+func TestParseRData(t *testing.T) {
+	rdata, err := ParseRData("SRV", "10 20 5060 sip.example.com.")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := DataBlock{Priority: "10", Weight: "20", Port: "5060", Target: "sip.example.com."}
+	if rdata != expected {
+		t.Fatalf("expected %#v, got %#v", expected, rdata)
+	}
+
+	// Spaces in base64 data are dropped, and trailing LOC fields are optional
+	rdata, err = ParseRData("SSHFP", "1 1 12345678 9abcdef")
+	if err != nil || rdata.Fingerprint != "123456789abcdef" {
+		t.Fatalf("unexpected fingerprint %q: %v", rdata.Fingerprint, err)
+	}
+	rdata, err = ParseRData("LOC", "42 21 54 N 71 6 18 W -24m")
+	if err != nil || rdata.Altitude != "-24" || rdata.Size != "" {
+		t.Fatalf("unexpected location %#v: %v", rdata, err)
+	}
+
+	for _, tc := range []struct {
+		recordType string
+		value      string
+	}{
+		{"HINFO", "PC Linux"},
+		{"MX", "mail.example.com."},
+		{"MX", "ten mail.example.com."},
+		{"SRV", "10 20 5060"},
+		{"SRV", "10 20 5060 sip.example.com. extra"},
+		{"SRV", "10 twenty 5060 sip.example.com."},
+		{"SRV", "10 20 http sip.example.com."},
+		{"SSHFP", "rsa 1 123456789abcdef67890123456789abcdef67890"},
+		{"TLSA", "3 1 sha256 0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6"},
+		{"DS", "60485 RSASHA1 1 2BB183AF5F22588179A53B0A98631FAD1A292118"},
+		{"DNSKEY", "256 3 RSASHA256 AwEAAaetidLzsKWUt4swWR8yu0wPHPiUi8LU"},
+		{"CAA", `0 issue "letsencrypt.org`},
+	} {
+		if _, err := ParseRData(tc.recordType, tc.value); err == nil {
+			t.Errorf("%s %q: expected an error", tc.recordType, tc.value)
+		}
+	}
+}
+
+func TestFlexStringJSON(t *testing.T) {
+	var rdata DataBlock
+	if err := json.Unmarshal([]byte(`{"port": 5060, "weight": "20", "priority": 10, "algorithm": null}`), &rdata); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if rdata.Port != "5060" || rdata.Weight != "20" || rdata.Algorithm != "" {
+		t.Fatalf("unexpected rdata: %#v", rdata)
+	}
+
+	js, err := json.Marshal(DataBlock{Port: "5060", Flags: "U", Tag: "issue"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if expected := `{"flags":"U","port":5060,"tag":"issue"}`; string(js) != expected {
+		t.Fatalf("expected %s, got %s", expected, js)
+	}
+}
@@ -9,4 +9,18 @@ type Record struct {
 	Type  string
 	FQDN  string
 	TTL   string
+	// RData, when set, is sent as is instead of parsing Value. GetRecord
+	// sets it to the data returned by the API.
+	RData *DataBlock
+}
+
+// RecordSet holds all the records of a type at a node, e.g. the A records
+// of a name served in round-robin.
+type RecordSet struct {
+	Zone string
+	FQDN string
+	Type string
+	TTL  string
+	// Values of the records, in the format of ParseRData
+	Values []string
 }
@@ -8,6 +8,15 @@ type AllRecordsResponse struct {
 	Data []string `json:"data"`
 }
 
+// Type AllRecordsDetailedResponse holds the records returned by an HTTP GET
+// call to https://api.dynect.net/REST/AllRecord/<zone>[/<FQDN>] with detail
+// set, indexed by the lower case type of the records followed by "_records",
+// e.g. "a_records".
+type AllRecordsDetailedResponse struct {
+	ResponseBlock
+	Data map[string][]BaseRecord `json:"data"`
+}
+
 // Type RecordResponse is used to hold the information for a single DNS record
 // returned from Dyn's DynECT API.
 type RecordResponse struct {
@@ -35,7 +44,8 @@ type BaseRecord struct {
 // holding record information.
 //
 // The comment above each field indicates which record types you can expect
-// the information to be provided.
+// the information to be provided. Fields of type FlexString hold numbers for
+// most record types.
 type DataBlock struct {
 	// A, AAAA
 	Address string `json:"address,omitempty" bson:"address,omitempty"`
@@ -44,15 +54,18 @@ type DataBlock struct {
 	Alias string `json:"alias,omitempty" bson:"alias,omitempty"`
 
 	// CERT, DNSKEY, DS, IPSECKEY, KEY, SSHFP
-	Algorithm string `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	Algorithm FlexString `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
 
 	// LOC
-	Altitude string `json:"altitude,omitempty" bson:"altitude,omitempty"`
+	Altitude FlexString `json:"altitude,omitempty" bson:"altitude,omitempty"`
 
 	// CNAME
 	CName string `json:"cname,omitempty" bson:"cname,omitempty"`
 
-	// CERT
+	// TLSA
+	CertUsage FlexString `json:"cert_usage,omitempty" bson:"cert_usage,omitempty"`
+
+	// CERT, TLSA
 	Certificate string `json:"certificate,omitempty" bson:"algorithm,omitempty"`
 
 	// DNAME
@@ -62,31 +75,34 @@ type DataBlock struct {
 	Digest string `json:"digest,omitempty" bson:"digest,omitempty"`
 
 	// DS
-	DigestType string `json:"digtype,omitempty" bson:"digest_type,omitempty"`
+	DigestType FlexString `json:"digtype,omitempty" bson:"digest_type,omitempty"`
 
 	// KX, MX
 	Exchange string `json:"exchange,omitempty" bson:"exchange,omitempty"`
 
 	// SSHFP
-	FPType string `json:"fptype,omitempty" bson:"fp_type,omitempty"`
+	FPType FlexString `json:"fptype,omitempty" bson:"fp_type,omitempty"`
 
 	// SSHFP
 	Fingerprint string `json:"fingerprint,omitempty" bson:"fingerprint,omitempty"`
 
-	// DNSKEY, KEY, NAPTR
-	Flags string `json:"flags,omitempty" bson:"flags,omitempty"`
+	// CAA, DNSKEY, KEY, NAPTR
+	Flags FlexString `json:"flags,omitempty" bson:"flags,omitempty"`
 
 	// CERT
-	Format string `json:"format,omitempty" bson:"format,omitempty"`
+	Format FlexString `json:"format,omitempty" bson:"format,omitempty"`
 
 	// IPSECKEY
-	GatewayType string `json:"gatetype,omitempty" bson:"gateway_type,omitempty"`
+	Gateway string `json:"gateway,omitempty" bson:"gateway,omitempty"`
+
+	// IPSECKEY
+	GatewayType FlexString `json:"gatetype,omitempty" bson:"gateway_type,omitempty"`
 
 	// LOC
-	HorizPre string `json:"horiz_pre,omitempty" bson:"horiz_pre,omitempty"`
+	HorizPre FlexString `json:"horiz_pre,omitempty" bson:"horiz_pre,omitempty"`
 
 	// DS
-	KeyTag string `json:"keytag,omitempty" bson:"keytag,omitempty"`
+	KeyTag FlexString `json:"keytag,omitempty" bson:"keytag,omitempty"`
 
 	// LOC
 	Latitude string `json:"latitude,omitempty" bson:"latitude,omitempty"`
@@ -100,6 +116,9 @@ type DataBlock struct {
 	// PX
 	MapX400 string `json:"mapx400,omitempty" bson:"map_x400,omitempty"`
 
+	// TLSA
+	MatchType FlexString `json:"match_type,omitempty" bson:"match_type,omitempty"`
+
 	// RP
 	Mbox string `json:"mbox,omitempty" bson:"mbox,omitempty"`
 
@@ -110,22 +129,22 @@ type DataBlock struct {
 	NSAP string `json:"nsap,omitempty" bson:"nsap,omitempty"`
 
 	// NAPTR
-	Order string `json:"order,omitempty" bson:"order,omitempty"`
+	Order FlexString `json:"order,omitempty" bson:"order,omitempty"`
 
 	// SRV
-	Port string `json:"port,omitempty" bson:"port,omitempty"`
+	Port FlexString `json:"port,omitempty" bson:"port,omitempty"`
 
 	// IPSECKEY
-	Precendence string `json:"precendence,omitempty" bson:"precendence,omitempty"`
+	Precendence FlexString `json:"precedence,omitempty" bson:"precedence,omitempty"`
 
 	// KX, MX, NAPTR, PX
-	Preference int `json:"preference,omitempty" bson:"preference,omitempty"`
+	Preference FlexString `json:"preference,omitempty" bson:"preference,omitempty"`
 
 	// SRV
-	Priority int `json:"priority,omitempty" bson:"priority,omitempty"`
+	Priority FlexString `json:"priority,omitempty" bson:"priority,omitempty"`
 
 	// DNSKEY, KEY
-	Protocol string `json:"protocol,omitempty" bson:"protocol,omitempty"`
+	Protocol FlexString `json:"protocol,omitempty" bson:"protocol,omitempty"`
 
 	// PTR
 	PTRDname string `json:"ptrdname,omitempty" bson:"ptrdname,omitempty"`
@@ -142,14 +161,17 @@ type DataBlock struct {
 	// SOA
 	RName string `json:"rname,omitempty" bson:"rname,omitempty"`
 
+	// TLSA
+	Selector FlexString `json:"selector,omitempty" bson:"selector,omitempty"`
+
 	// NAPTR
 	Services string `json:"services,omitempty" bson:"services,omitempty"`
 
 	// LOC
-	Size string `json:"size,omitempty" bson:"size,omitempty"`
+	Size FlexString `json:"size,omitempty" bson:"size,omitempty"`
 
-	// CERT
-	Tag string `json:"tag,omitempty" bson:"tag,omitempty"`
+	// CAA, CERT
+	Tag FlexString `json:"tag,omitempty" bson:"tag,omitempty"`
 
 	// SRV
 	Target string `json:"target,omitempty" bson:"target,omitempty"`
@@ -160,12 +182,15 @@ type DataBlock struct {
 	// SPF, TXT
 	TxtData string `json:"txtdata,omitempty" bson:"txtdata,omitempty"`
 
+	// CAA
+	Value string `json:"value,omitempty" bson:"value,omitempty"`
+
 	// LOC
-	Version string `json:"version,omitempty" bson:"version,omitempty"`
+	Version FlexString `json:"version,omitempty" bson:"version,omitempty"`
 
 	// LOC
-	VertPre string `json:"vert_pre,omitempty" bson:"vert_pre,omitempty"`
+	VertPre FlexString `json:"vert_pre,omitempty" bson:"vert_pre,omitempty"`
 
 	// SRV
-	Weight string `json:"weight,omitempty" bson:"weight,omitempty"`
+	Weight FlexString `json:"weight,omitempty" bson:"weight,omitempty"`
 }
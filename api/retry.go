@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests rejected by the DynECT API with a 429
+// (Too Many Requests) status are retried.
+//
+// A rate limited request has not been processed by the API, so it is safe to
+// send it again whatever its method. Requests failing for another reason,
+// e.g. a network error, are never retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times a request is retried. Zero
+	// disables retries.
+	MaxRetries int
+
+	// MinWait is the wait before the first retry. It is doubled on every
+	// retry, and a random jitter of up to half of it is removed.
+	MinWait time.Duration
+
+	// MaxWait caps the computed wait between two retries. A longer wait
+	// requested by the API through the Retry-After header is honored.
+	MaxWait time.Duration
+
+	// MaxElapsed caps the total time spent waiting to retry a request. Zero
+	// means no limit.
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of new clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	MinWait:    1 * time.Second,
+	MaxWait:    30 * time.Second,
+	MaxElapsed: 5 * time.Minute,
+}
+
+// backoff returns the wait before the given retry, counted from 0, when the
+// API did not send a Retry-After header.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.MinWait
+	for i := 0; i < retry && (p.MaxWait <= 0 || wait < p.MaxWait); i++ {
+		wait *= 2
+	}
+	if p.MaxWait > 0 && wait > p.MaxWait {
+		wait = p.MaxWait
+	}
+	if half := int64(wait / 2); half > 0 {
+		wait -= time.Duration(rand.Int63n(half))
+	}
+	return wait
+}
+
+// retryAfter parses the value of a Retry-After header, either a number of
+// seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// roundTrip sends a request, retrying it according to the client's retry
+// policy while it is rate limited.
+//
+// The last response is returned when the policy is exhausted, so its 429
+// status must still be handled by the caller.
+func (c *Client) roundTrip(ctx context.Context, method, urlStr string, data []byte) (*http.Response, error) {
+	var waited time.Duration
+
+	for retry := 0; ; retry++ {
+		req, err := c.newRequest(ctx, method, urlStr, data)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 429 || retry >= c.Retry.MaxRetries {
+			return resp, nil
+		}
+
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = c.Retry.backoff(retry)
+		}
+		if c.Retry.MaxElapsed > 0 && waited+wait > c.Retry.MaxElapsed {
+			log.Printf("[WARN] dynect: %s %s was rate limited, giving up after waiting %s", method, urlStr, waited)
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		log.Printf("[WARN] dynect: %s %s was rate limited, retrying in %s (retry %d of %d)",
+			method, urlStr, wait, retry+1, c.Retry.MaxRetries)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		waited += wait
+	}
+}
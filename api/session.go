@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// isSessionExpired reports whether err means that the API rejected the
+// request because the session token is invalid or expired.
+//
+// The API answers such requests with a 400 status and a message about the
+// login or token, e.g. "login: Bad or expired credentials".
+func isSessionExpired(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	if e.StatusCode == 401 {
+		return true
+	}
+	if e.StatusCode != 400 {
+		return false
+	}
+	for _, msg := range e.Messages {
+		if strings.HasPrefix(msg.Info, "login:") || strings.HasPrefix(msg.Info, "token:") {
+			return true
+		}
+	}
+	return false
+}
+
+// canRenewSession reports whether a request rejected because of an expired
+// session may be replayed after logging in again.
+func (c *Client) canRenewSession(method, endpoint string) bool {
+	if c.username == "" {
+		return false
+	}
+	// Logging in or out again makes no sense
+	return endpoint != "Session" || method == "PUT" || method == "GET"
+}
+
+// publishedZone returns the zone published by a request, if it is a publish.
+func publishedZone(method, endpoint string, requestData interface{}) (string, bool) {
+	if method != "PUT" || !strings.HasPrefix(endpoint, "Zone/") {
+		return "", false
+	}
+	switch data := requestData.(type) {
+	case *PublishZoneBlock:
+		return strings.TrimPrefix(endpoint, "Zone/"), data.Publish
+	case PublishZoneBlock:
+		return strings.TrimPrefix(endpoint, "Zone/"), data.Publish
+	}
+	return "", false
+}
+
+// login establishes a new session, without locking the client.
+func (c *Client) login(ctx context.Context, username, password string) error {
+	var req = LoginBlock{
+		Username:     username,
+		Password:     password,
+		CustomerName: c.CustomerName}
+
+	var resp LoginResponse
+
+	err := c.do(ctx, "POST", "Session", req, &resp)
+	if err != nil {
+		return err
+	}
+
+	c.Token = resp.Data.Token
+	c.username = username
+	c.password = password
+	return nil
+}
+
+// KeepAlive extends the session, so that it does not expire while idle.
+func (c *Client) KeepAlive() error {
+	return c.KeepAliveContext(context.Background())
+}
+
+// KeepAliveContext is KeepAlive, with a context to cancel the request.
+//
+// If the session already expired, the client logs in again.
+func (c *Client) KeepAliveContext(ctx context.Context) error {
+	return c.DoContext(ctx, "PUT", "Session", nil, nil)
+}
+
+// IdleSince returns when the client last sent a request.
+func (c *Client) IdleSince() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lastUsed
+}
@@ -1,5 +1,7 @@
 package api
 
+import "fmt"
+
 // ZonesResponse is used for holding the data returned by a call to
 // "https://api.dynect.net/REST/Zone/".
 type ZonesResponse struct {
@@ -22,3 +24,63 @@ type ZoneDataBlock struct {
 	Zone        string `json:"zone"`
 	ZoneType    string `json:"zone_type"`
 }
+
+// CreateZoneBlock holds the request body for a zone creation
+// https://help.dyn.com/create-primary-zone-api/
+type CreateZoneBlock struct {
+	RName       string `json:"rname"`
+	SerialStyle string `json:"serial_style,omitempty"`
+	TTL         int    `json:"ttl"`
+}
+
+// UpdateSOABlock holds the request body for an update of the SOA record of
+// a zone, which also sets the serial style of the zone.
+// https://help.dyn.com/update-soa-record-api/
+type UpdateSOABlock struct {
+	RData       DataBlock `json:"rdata"`
+	TTL         string    `json:"ttl,omitempty"`
+	SerialStyle string    `json:"serial_style,omitempty"`
+}
+
+// Zone simple struct to hold primary zone details
+type Zone struct {
+	Name string
+	// Email of the contact of the zone, in the SOA record
+	RName string
+	// TTL of the SOA record
+	TTL         int
+	SerialStyle string
+	Serial      int
+	Type        string
+}
+
+// ZoneChangesResponse is used for holding the data returned by a call to
+// "https://api.dynect.net/REST/ZoneChanges/ZONE_NAME".
+// https://help.dyn.com/get-zone-changes-api/
+type ZoneChangesResponse struct {
+	ResponseBlock
+	Data []ZoneChange `json:"data"`
+}
+
+// ZoneChange is a change made to a zone in a session, which is not published
+// yet.
+type ZoneChange struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Zone      string    `json:"zone"`
+	FQDN      string    `json:"fqdn"`
+	Serial    int       `json:"serial"`
+	TTL       int       `json:"ttl"`
+	RDataType string    `json:"rdata_type"`
+	RData     DataBlock `json:"rdata"`
+}
+
+// String formats the record of a change as "TYPE FQDN VALUE", e.g.
+// "A www.example.com 192.168.0.10".
+func (c ZoneChange) String() string {
+	value, err := FormatRData(c.RDataType, c.RData)
+	if err != nil {
+		return fmt.Sprintf("%s %s", c.RDataType, c.FQDN)
+	}
+	return fmt.Sprintf("%s %s %s", c.RDataType, c.FQDN, value)
+}
@@ -1,8 +1,10 @@
 package dyn
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
@@ -12,21 +14,34 @@ type Config struct {
 	CustomerName string
 	Username     string
 	Password     string
+	Endpoint     string
+	MaxRetries   int
+	RetryMaxWait time.Duration
 }
 
 // Client() returns a new client for accessing dyn.
 func (c *Config) Client() (*api.ConvenientClient, error) {
+	return c.ClientContext(context.Background())
+}
+
+// ClientContext returns a new client for accessing dyn, logging in with ctx.
+func (c *Config) ClientContext(ctx context.Context) (*api.ConvenientClient, error) {
 	client := api.NewConvenientClient(c.CustomerName)
+	client.SetEndpoint(c.Endpoint)
+	client.Retry.MaxRetries = c.MaxRetries
+	if c.RetryMaxWait > 0 {
+		client.Retry.MaxWait = c.RetryMaxWait
+	}
 	if logging.IsDebugOrHigher() {
 		client.Verbose(true)
 	}
 
-	err := client.Login(c.Username, c.Password)
+	err := client.LoginContext(ctx, c.Username, c.Password)
 	if err != nil {
 		return nil, fmt.Errorf("Error setting up Dyn client: %s", err)
 	}
 
-	log.Printf("[INFO] Dyn client configured for customer: %s, user: %s", c.CustomerName, c.Username)
+	log.Printf("[INFO] Dyn client configured for customer: %s, user: %s, endpoint: %s", c.CustomerName, c.Username, client.Endpoint)
 
 	return client, nil
 }
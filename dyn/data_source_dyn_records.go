@@ -0,0 +1,148 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceDynRecords() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the published records of a zone, or of a node of the zone.",
+
+		ReadContext: dataSourceDynRecordsRead,
+
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the zone.",
+			},
+			"fqdn": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Fully qualified domain name of a node, to only list its records.",
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(api.RecordTypes(), false),
+				Description:  "Type of the listed records, e.g. `NS`.",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Regular expression the fully qualified domain names of the listed records must match.",
+			},
+			"records": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Records, ordered by fully qualified domain name, type and ID.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"record_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ID of the record.",
+						},
+						"fqdn": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Fully qualified domain name of the record.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the record in the zone, empty for the apex of the zone.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Type of the record.",
+						},
+						"ttl": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "TTL of the record.",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Value of the record, in the format documented on `dyn_record`.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDynRecordsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	zone := d.Get("zone").(string)
+	fqdn := d.Get("fqdn").(string)
+	records, err := client.GetAllRecordsContext(ctx, zone, fqdn)
+	if err != nil {
+		return diag.Errorf("Couldn't list Dyn records of %s: %s", zone, err)
+	}
+
+	recordType := d.Get("type").(string)
+	nameRegex := regexp.MustCompile(d.Get("name_regex").(string))
+	var matches []api.Record
+	for _, record := range records {
+		if recordType != "" && record.Type != recordType {
+			continue
+		}
+		if !nameRegex.MatchString(record.FQDN) {
+			continue
+		}
+		matches = append(matches, record)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].FQDN != matches[j].FQDN {
+			return matches[i].FQDN < matches[j].FQDN
+		}
+		if matches[i].Type != matches[j].Type {
+			return matches[i].Type < matches[j].Type
+		}
+		// IDs are numbers
+		if len(matches[i].ID) != len(matches[j].ID) {
+			return len(matches[i].ID) < len(matches[j].ID)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	list := make([]interface{}, 0, len(matches))
+	for _, record := range matches {
+		ttl, _ := strconv.Atoi(record.TTL)
+		list = append(list, map[string]interface{}{
+			"record_id": record.ID,
+			"fqdn":      record.FQDN,
+			"name":      record.Name,
+			"type":      record.Type,
+			"ttl":       ttl,
+			"value":     record.Value,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s/%s/%s", zone, fqdn, recordType, d.Get("name_regex").(string)))
+	if err := d.Set("records", list); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
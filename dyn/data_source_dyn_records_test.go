@@ -0,0 +1,80 @@
+package dyn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynRecordsDataSource(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	for _, config := range []map[string]interface{}{
+		{"name": "www", "type": "A", "value": "192.168.0.10"},
+		{"name": "www", "type": "A", "value": "192.168.0.11"},
+		{"name": "mail", "type": "MX", "value": "10 mx.example.com."},
+		{"name": "_sip._tcp", "type": "SRV", "value": "10 20 5060 sip.example.com."},
+	} {
+		config["zone"] = "example.com"
+		d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, config)
+		if err := resourceDynRecordCreate(d, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		config   map[string]interface{}
+		expected []string
+	}{
+		{
+			map[string]interface{}{},
+			[]string{
+				"SRV 10 20 5060 sip.example.com.",
+				"SOA hostmaster@example.com",
+				"MX 10 mx.example.com.",
+				"A 192.168.0.10",
+				"A 192.168.0.11",
+			},
+		},
+		{
+			map[string]interface{}{"type": "A"},
+			[]string{"A 192.168.0.10", "A 192.168.0.11"},
+		},
+		{
+			map[string]interface{}{"fqdn": "mail.example.com"},
+			[]string{"MX 10 mx.example.com."},
+		},
+		{
+			map[string]interface{}{"name_regex": `^_`},
+			[]string{"SRV 10 20 5060 sip.example.com."},
+		},
+	} {
+		tc.config["zone"] = "example.com"
+		d := schema.TestResourceDataRaw(t, dataSourceDynRecords().Schema, tc.config)
+		if diags := dataSourceDynRecordsRead(context.Background(), d, provider); diags.HasError() {
+			t.Fatalf("err: %#v", diags)
+		}
+
+		records := d.Get("records").([]interface{})
+		if len(records) != len(tc.expected) {
+			t.Fatalf("%v: expected %d records, got %v", tc.config, len(tc.expected), records)
+		}
+		for i, r := range records {
+			record := r.(map[string]interface{})
+			if got := record["type"].(string) + " " + record["value"].(string); got != tc.expected[i] {
+				t.Errorf("%v: expected record %d to be %q, got %q", tc.config, i, tc.expected[i], got)
+			}
+			if record["record_id"].(string) == "" || record["ttl"].(int) == 0 {
+				t.Errorf("%v: expected record %d to have an ID and a TTL: %v", tc.config, i, record)
+			}
+			if record["type"].(string) == "SOA" && record["name"].(string) != "" {
+				t.Errorf("%v: expected the apex record to have an empty name, got %q", tc.config, record["name"])
+			}
+		}
+	}
+}
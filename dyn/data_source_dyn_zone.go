@@ -0,0 +1,74 @@
+package dyn
+
+import (
+	"context"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDynZone() *schema.Resource {
+	return &schema.Resource{
+		Description: "Looks up a zone of the customer account.",
+
+		ReadContext: dataSourceDynZoneRead,
+
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the zone, e.g. `example.com`.",
+			},
+			"rname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Email of the administrative contact of the zone, from its SOA record.",
+			},
+			"ttl": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Default TTL of the zone, from its SOA record.",
+			},
+			"serial": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Current serial of the zone.",
+			},
+			"serial_style": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Style of the serial of the zone: `increment`, `epoch`, `day` or `minute`.",
+			},
+			"zone_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of the zone, `Primary` or `Secondary`.",
+			},
+		},
+	}
+}
+
+func dataSourceDynZoneRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	zone := &api.Zone{Name: d.Get("zone").(string)}
+	err = client.GetZoneContext(ctx, zone)
+	if err != nil {
+		return diag.Errorf("Couldn't find Dyn zone %s: %s", zone.Name, err)
+	}
+
+	d.SetId(zone.Name)
+	d.Set("rname", zone.RName)
+	d.Set("ttl", zone.TTL)
+	d.Set("serial", zone.Serial)
+	d.Set("serial_style", zone.SerialStyle)
+	d.Set("zone_type", zone.Type)
+
+	return nil
+}
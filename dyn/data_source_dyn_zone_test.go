@@ -0,0 +1,73 @@
+package dyn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynZoneDataSource(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, dataSourceDynZone().Schema, map[string]interface{}{
+		"zone": "example.com",
+	})
+	if diags := dataSourceDynZoneRead(context.Background(), d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if serial := d.Get("serial").(int); serial != server.Serial("example.com") {
+		t.Fatalf("unexpected serial: %d", serial)
+	}
+	if rname := d.Get("rname").(string); rname != "hostmaster@example.com" {
+		t.Fatalf("unexpected rname: %s", rname)
+	}
+	if style := d.Get("serial_style").(string); style != "increment" {
+		t.Fatalf("unexpected serial style: %s", style)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceDynZone().Schema, map[string]interface{}{
+		"zone": "missing.com",
+	})
+	if diags := dataSourceDynZoneRead(context.Background(), d, provider); !diags.HasError() {
+		t.Fatal("expected a missing zone to fail")
+	}
+}
+
+func TestDynZonesDataSource(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	server.AddZone("example.org")
+	server.AddZone("test.com")
+	provider := testDynProvider(t, server)
+
+	for _, tc := range []struct {
+		regex    string
+		expected []string
+	}{
+		{"", []string{"example.com", "example.org", "test.com"}},
+		{`\.com$`, []string{"example.com", "test.com"}},
+		{`^example\.`, []string{"example.com", "example.org"}},
+		{`^none$`, []string{}},
+	} {
+		d := schema.TestResourceDataRaw(t, dataSourceDynZones().Schema, map[string]interface{}{
+			"name_regex": tc.regex,
+		})
+		if diags := dataSourceDynZonesRead(context.Background(), d, provider); diags.HasError() {
+			t.Fatalf("err: %#v", diags)
+		}
+		names := []string{}
+		for _, name := range d.Get("names").([]interface{}) {
+			names = append(names, name.(string))
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.regex, tc.expected, names)
+		}
+	}
+}
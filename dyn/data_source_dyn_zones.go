@@ -0,0 +1,63 @@
+package dyn
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceDynZones() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the zones of the customer account.",
+
+		ReadContext: dataSourceDynZonesRead,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Regular expression the names of the listed zones must match, e.g. `\\.com$`.",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Names of the zones, in alphabetical order.",
+			},
+		},
+	}
+}
+
+func dataSourceDynZonesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	zones, err := client.GetZonesContext(ctx)
+	if err != nil {
+		return diag.Errorf("Couldn't list Dyn zones: %s", err)
+	}
+
+	names := make([]string, 0, len(zones))
+	nameRegex := d.Get("name_regex").(string)
+	re := regexp.MustCompile(nameRegex)
+	for _, zone := range zones {
+		if re.MatchString(zone) {
+			names = append(names, zone)
+		}
+	}
+	sort.Strings(names)
+
+	d.SetId(client.CustomerName + "/" + nameRegex)
+	d.Set("names", names)
+
+	return nil
+}
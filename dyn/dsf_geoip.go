@@ -0,0 +1,168 @@
+package dyn
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// Region codes of the geoip criteria of Traffic Director rulesets
+var dsfGeoIPRegions = []string{
+	"11", // US West
+	"12", // US Central
+	"13", // US East
+	"14", // Asia
+	"15", // EU West
+	"16", // EU Central
+	"17", // EU East
+	"18", // Oceania
+	"19", // South America
+	"20", // Africa
+}
+
+// Country codes of the geoip criteria of Traffic Director rulesets: the ISO
+// 3166-1 alpha-2 codes, and the codes of the GeoIP database for anonymous
+// proxies (A1), satellite providers (A2), Asia/Pacific (AP), Europe (EU) and
+// other countries (O1).
+var dsfGeoIPCountries = []string{
+	"A1", "A2", "AP", "EU", "O1",
+	"AD", "AE", "AF", "AG", "AI", "AL", "AM", "AO", "AQ", "AR", "AS", "AT", "AU", "AW", "AX", "AZ",
+	"BA", "BB", "BD", "BE", "BF", "BG", "BH", "BI", "BJ", "BL", "BM", "BN", "BO", "BQ", "BR", "BS", "BT", "BV", "BW", "BY", "BZ",
+	"CA", "CC", "CD", "CF", "CG", "CH", "CI", "CK", "CL", "CM", "CN", "CO", "CR", "CU", "CV", "CW", "CX", "CY", "CZ",
+	"DE", "DJ", "DK", "DM", "DO", "DZ",
+	"EC", "EE", "EG", "EH", "ER", "ES", "ET",
+	"FI", "FJ", "FK", "FM", "FO", "FR",
+	"GA", "GB", "GD", "GE", "GF", "GG", "GH", "GI", "GL", "GM", "GN", "GP", "GQ", "GR", "GS", "GT", "GU", "GW", "GY",
+	"HK", "HM", "HN", "HR", "HT", "HU",
+	"ID", "IE", "IL", "IM", "IN", "IO", "IQ", "IR", "IS", "IT",
+	"JE", "JM", "JO", "JP",
+	"KE", "KG", "KH", "KI", "KM", "KN", "KP", "KR", "KW", "KY", "KZ",
+	"LA", "LB", "LC", "LI", "LK", "LR", "LS", "LT", "LU", "LV", "LY",
+	"MA", "MC", "MD", "ME", "MF", "MG", "MH", "MK", "ML", "MM", "MN", "MO", "MP", "MQ", "MR", "MS", "MT", "MU", "MV", "MW", "MX", "MY", "MZ",
+	"NA", "NC", "NE", "NF", "NG", "NI", "NL", "NO", "NP", "NR", "NU", "NZ",
+	"OM",
+	"PA", "PE", "PF", "PG", "PH", "PK", "PL", "PM", "PN", "PR", "PS", "PT", "PW", "PY",
+	"QA",
+	"RE", "RO", "RS", "RU", "RW",
+	"SA", "SB", "SC", "SD", "SE", "SG", "SH", "SI", "SJ", "SK", "SL", "SM", "SN", "SO", "SR", "SS", "ST", "SV", "SX", "SY", "SZ",
+	"TC", "TD", "TF", "TG", "TH", "TJ", "TK", "TL", "TM", "TN", "TO", "TR", "TT", "TV", "TW", "TZ",
+	"UA", "UG", "UM", "US", "UY", "UZ",
+	"VA", "VC", "VE", "VG", "VI", "VN", "VU",
+	"WF", "WS",
+	"YE", "YT",
+	"ZA", "ZM", "ZW",
+}
+
+func dsfCriteriaTypeSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      "always",
+		ValidateFunc: validation.StringInSlice([]string{"always", "geoip"}, false),
+		Description: `Criteria of the clients served by the Ruleset. Defaults to always.
+  * always — Serves every client.
+  * geoip — Serves the clients located in the regions, countries or provinces of the geoip block.`,
+	}
+}
+
+// dsfGeoIPSchema returns the schema of the geoip block of a ruleset, whose
+// attributes are required to be set together with atLeastOneOf when the
+// block is not nested in a list.
+func dsfGeoIPSchema(atLeastOneOf []string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Locations of the clients served by the Ruleset, required when criteria_type is geoip.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"region": {
+					Type:         schema.TypeSet,
+					Optional:     true,
+					AtLeastOneOf: atLeastOneOf,
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringInSlice(dsfGeoIPRegions, false),
+					},
+					Description: "Dyn region codes: `11` US West, `12` US Central, `13` US East, `14` Asia, `15` EU West, `16` EU Central, `17` EU East, `18` Oceania, `19` South America, `20` Africa.",
+				},
+				"country": {
+					Type:         schema.TypeSet,
+					Optional:     true,
+					AtLeastOneOf: atLeastOneOf,
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringInSlice(dsfGeoIPCountries, false),
+					},
+					Description: "ISO 3166-1 alpha-2 country codes, e.g. `FR`.",
+				},
+				"province": {
+					Type:         schema.TypeSet,
+					Optional:     true,
+					AtLeastOneOf: atLeastOneOf,
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringIsNotWhiteSpace,
+					},
+					Description: "Province or state codes of the United States and Canada.",
+				},
+			},
+		},
+	}
+}
+
+// checkDSFCriteria checks that the geoip block of the ruleset at path is set
+// on geoip rulesets only, and is not empty.
+func checkDSFCriteria(path, criteriaType string, geoip []interface{}, geoipKnown bool) error {
+	switch {
+	case criteriaType == "geoip" && len(geoip) == 0 && geoipKnown:
+		return fmt.Errorf("%sgeoip: block is required when criteria_type is geoip", path)
+	case criteriaType != "geoip" && len(geoip) > 0:
+		return fmt.Errorf("%sgeoip: block can only be set when criteria_type is geoip, not %s", path, criteriaType)
+	}
+	if len(geoip) > 0 && geoipKnown {
+		if location := expandDSFCriteria(geoip).GeoIP; location == nil ||
+			len(location.Region)+len(location.Country)+len(location.Province) == 0 {
+			return fmt.Errorf("%sgeoip: one of region, country or province must be specified", path)
+		}
+	}
+	return nil
+}
+
+func expandDSFCriteria(v []interface{}) *api.DSFCriteria {
+	criteria := &api.DSFCriteria{}
+	if len(v) > 0 && v[0] != nil {
+		geoip := v[0].(map[string]interface{})
+		criteria.GeoIP = &api.DSFGeoIPCriteria{
+			Region:   expandStringSet(geoip["region"]),
+			Country:  expandStringSet(geoip["country"]),
+			Province: expandStringSet(geoip["province"]),
+		}
+	}
+	return criteria
+}
+
+func expandStringSet(v interface{}) []string {
+	var values []string
+	for _, value := range v.(*schema.Set).List() {
+		values = append(values, value.(string))
+	}
+	sort.Strings(values)
+	return values
+}
+
+func flattenDSFCriteria(criteria *api.DSFCriteria) []interface{} {
+	if criteria.GeoIP == nil {
+		return []interface{}{}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"region":   criteria.GeoIP.Region,
+			"country":  criteria.GeoIP.Country,
+			"province": criteria.GeoIP.Province,
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// splitDSFImportID splits the {traffic_director_id}/{id} import ID of the
+// objects of a Traffic Director service, and sets it in d.
+func splitDSFImportID(d *schema.ResourceData, object string) error {
+	values := strings.Split(d.Id(), "/")
+	if len(values) != 2 || values[0] == "" || values[1] == "" {
+		return fmt.Errorf("invalid id provided, expected format: {traffic_director_id}/{%s_id}", object)
+	}
+
+	d.SetId(values[1])
+	d.Set("traffic_director_id", values[0])
+	return nil
+}
+
+// The parent IDs the importers do not set are read back from the objects.
+
+func resourceDynDSFRulesetImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := splitDSFImportID(d, "ruleset"); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDynDSFResponsePoolImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := splitDSFImportID(d, "response_pool"); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDynDSFRsfcImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := splitDSFImportID(d, "rsfc"); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceDynDsfRecordImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := splitDSFImportID(d, "record"); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceDynDSFRecordSetImportState looks up the failover chain and the
+// response pool of the record set, which Dyn only returns with the chains.
+func resourceDynDSFRecordSetImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := splitDSFImportID(d, "record_set"); err != nil {
+		return nil, err
+	}
+	traffic_director_id := d.Get("traffic_director_id").(string)
+
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.PutClient(client)
+
+	response := &api.AllDSFRsfcResponse{}
+	url := fmt.Sprintf("DSFRecordSetFailoverChain/%s", traffic_director_id)
+	err = client.DoContext(ctx, "GET", url, nil, response)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chain := range response.Data {
+		for _, recordSet := range chain.DSFRecordSets {
+			if recordSet.ID == d.Id() {
+				d.Set("dsf_rsfc_id", chain.ID)
+				d.Set("response_pool_id", chain.DSFResponsePoolID)
+				return []*schema.ResourceData{d}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Dyn Traffic Director record set %s not found in %s", d.Id(), traffic_director_id)
+}
@@ -19,44 +19,47 @@ func resourceDynRecordImportState(d *schema.ResourceData, meta interface{}) ([]*
 	}
 	defer provider.PutClient(client)
 
-	values := strings.Split(d.Id(), "/")
+	// The value may contain slashes
+	values := strings.SplitN(d.Id(), "/", 4)
 
 	if len(values) != 3 && len(values) != 4 {
-		return nil, fmt.Errorf("invalid id provided, expected format: {type}/{zone}/{fqdn}[/{id}]")
+		return nil, fmt.Errorf("invalid id provided, expected format: {type}/{zone}/{fqdn}[/{id}|/value={value}]")
 	}
 
 	recordType := values[0]
 	recordZone := values[1]
 	recordFQDN := values[2]
 
-	var recordID string
+	var recordID, recordValue string
 	if len(values) == 4 {
-		recordID = values[3]
-	} else {
-		recordID = ""
+		if strings.HasPrefix(values[3], "value=") {
+			recordValue = strings.TrimPrefix(values[3], "value=")
+		} else {
+			recordID = values[3]
+		}
 	}
 
 	record := &api.Record{
 		ID:    recordID,
 		Name:  "",
 		Zone:  recordZone,
-		Value: "",
+		Value: recordValue,
 		Type:  recordType,
 		FQDN:  recordFQDN,
 		TTL:   "",
 	}
 
-	// If we already have the record ID, use it for the lookup
+	// Look up the record ID unless we already have it, matching the value
+	// if several records share the FQDN
 	if record.ID == "" {
 		err := client.GetRecordID(record)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		err := client.GetRecord(record)
-		if err != nil {
-			return nil, err
-		}
+	}
+	err = client.GetRecord(record)
+	if err != nil {
+		return nil, err
 	}
 
 	d.SetId(record.ID)
@@ -3,9 +3,12 @@ package dyn
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -108,3 +111,46 @@ func compareState(recordState *terraform.InstanceState, expectedName, expectedVa
 
 	return nil
 }
+
+func TestDynRecord_siblings(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	records := map[string]*schema.ResourceData{}
+	for _, value := range []string{"192.168.0.10", "192.168.0.11", "192.168.0.12"} {
+		d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+			"zone":  "example.com",
+			"name":  "www",
+			"type":  "A",
+			"value": value,
+		})
+		if err := resourceDynRecordCreate(d, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := resourceDynRecordRead(d, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if got := d.Get("value").(string); got != value {
+			t.Fatalf("expected the record of %s, got the record of %s", value, got)
+		}
+		records[value] = d
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{})
+	d.SetId("A/example.com/www.example.com/value=192.168.0.11")
+	imported, err := resourceDynRecordImportState(d, provider)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if id := imported[0].Id(); id != records["192.168.0.11"].Id() {
+		t.Fatalf("expected the record %s, got %s", records["192.168.0.11"].Id(), id)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{})
+	d.SetId("A/example.com/www.example.com")
+	if _, err := resourceDynRecordImportState(d, provider); err == nil || !strings.Contains(err.Error(), "Found 3 A records") {
+		t.Fatalf("expected an ambiguous import to fail, got %v", err)
+	}
+}
@@ -1,10 +1,15 @@
 package dyn
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // Provider returns a terraform.ResourceProvider.
@@ -32,40 +37,202 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("DYN_PASSWORD", nil),
 				Description: "The Dyn password.",
 			},
+
+			"endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("DYN_API_ENDPOINT", api.DynAPIPrefix),
+				Description: "Base URL of the DynECT REST API. Defaults to `" + api.DynAPIPrefix + "`.",
+			},
+
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      api.DefaultRetryPolicy.MaxRetries,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of retries of a request rate limited by the Dyn API. `0` disables retries.",
+			},
+
+			"retry_max_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(api.DefaultRetryPolicy.MaxWait / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum wait, in seconds, between two retries of a rate limited request. A longer wait requested by the Dyn API with a `Retry-After` header is honored.",
+			},
+
+			"max_sessions": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of Dyn sessions opened at once. Operations wait for a free session once the limit is reached. `0` means no limit.",
+			},
+
+			"publish_notes": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Notes sent with every publish of a zone or Traffic Director service, shown in their change history, e.g. `terraform: $${workspace} $${resource_type}`. " + publishNotesVariables,
+			},
+
+			"stage_traffic_director_changes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Create and update Traffic Director objects without publishing them, " +
+					"so that a `dyn_traffic_director_publish` resource publishes the whole service at once. " +
+					"Every staged object must feed the `triggers` of the `dyn_traffic_director_publish` resource, or its changes are left unpublished. " +
+					"Deletes are published at once. Defaults to `false`.",
+			},
+
+			"pending_zone_changes": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "fail",
+				ValidateFunc: validation.StringInSlice([]string{"fail", "discard"}, false),
+				Description: "What to do with the unpublished changes to a zone found in a Dyn session before changing the zone, e.g. left by a failed operation, " +
+					"so that they are not published along. `fail` lists them in an error and logs out of the session, `discard` discards them. Defaults to `fail`.",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"dyn_record":            resourceDynRecord(),
-			"dyn_traffic_director":  resourceDynTrafficDirector(),
-			"dyn_dsf_ruleset":       resourceDynDSFRuleset(),
-			"dyn_dsf_response_pool": resourceDynDSFResponsePool(),
-			"dyn_dsf_rsfc":          resourceDynDSFRsfc(),
-			"dyn_dsf_record_set":    resourceDynDSFRecordSet(),
-			"dyn_dsf_record":        resourceDynDsfRecord(),
-			"dyn_dsf_monitor":       resourceDynDSFMonitor(),
+			"dyn_record":                         resourceDynRecord(),
+			"dyn_record_set":                     resourceDynRecordSet(),
+			"dyn_zone":                           resourceDynZone(),
+			"dyn_traffic_director":               resourceDynTrafficDirector(),
+			"dyn_traffic_director_publish":       resourceDynTrafficDirectorPublish(),
+			"dyn_traffic_director_service":       resourceDynTrafficDirectorService(),
+			"dyn_traffic_director_ruleset_order": resourceDynTrafficDirectorRulesetOrder(),
+			"dyn_dsf_ruleset":                    resourceDynDSFRuleset(),
+			"dyn_dsf_response_pool":              resourceDynDSFResponsePool(),
+			"dyn_dsf_rsfc":                       resourceDynDSFRsfc(),
+			"dyn_dsf_record_set":                 resourceDynDSFRecordSet(),
+			"dyn_dsf_record":                     resourceDynDsfRecord(),
+			"dyn_dsf_monitor":                    resourceDynDSFMonitor(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"dyn_zone":    dataSourceDynZone(),
+			"dyn_zones":   dataSourceDynZones(),
+			"dyn_records": dataSourceDynRecords(),
 		},
 
 		ConfigureFunc: providerConfigure,
 	}
 }
 
+// Pooled clients idle for longer are sent a keep-alive before being reused.
+const sessionKeepAliveAfter = 10 * time.Minute
+
 type DynProvider struct {
 	config  *Config
 	clients []*api.ConvenientClient
 	mutex   sync.Mutex
+
+	// Number of open sessions, either idle in clients or in use, and its
+	// upper bound (0 means unbounded).
+	sessions    int
+	maxSessions int
+	// Callers of GetClient waiting for a session to be released. They
+	// receive a client, or nil when they may open a new session.
+	waiters []chan *api.ConvenientClient
+
+	// Pool metrics
+	created int
+	reused  int
+	waited  int
+
+	// Batches of changes waiting to be published, by zone, and the time to
+	// wait for more changes before publishing a batch.
+	zoneBatches  map[string]*zoneBatch
+	publishDelay time.Duration
+	// Whether to discard the unpublished changes found in the session of a
+	// batch, rather than failing.
+	discardZoneChanges bool
+
+	// Template of the notes sent with publishes
+	publishNotesTemplate string
+	// Whether to leave the changes to Traffic Director services unpublished
+	stageTrafficDirectorChanges bool
+
+	// Context of the work which outlives the resource operations starting
+	// it, e.g. publishing a batch of changes. It is cancelled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Get a client from the pool, creating a new one if necessary
 func (p *DynProvider) GetClient() (*api.ConvenientClient, error) {
+	return p.GetClientContext(context.Background())
+}
+
+// Get a client from the pool, logging in a new one with ctx if necessary.
+//
+// When max_sessions sessions are already open, it blocks until one is put
+// back to the pool, or until ctx is done.
+func (p *DynProvider) GetClientContext(ctx context.Context) (*api.ConvenientClient, error) {
 	p.mutex.Lock()
-	if len(p.clients) > 0 {
-		client := p.clients[len(p.clients)-1]
-		p.clients = p.clients[:len(p.clients)-1]
+	for {
+		if len(p.clients) > 0 {
+			client := p.clients[len(p.clients)-1]
+			p.clients = p.clients[:len(p.clients)-1]
+			p.reused++
+			p.logMetrics("reusing session")
+			p.mutex.Unlock()
+			if err := keepAlive(ctx, client); err != nil {
+				p.discardClient()
+				return nil, err
+			}
+			return client, nil
+		}
+
+		if p.maxSessions <= 0 || p.sessions < p.maxSessions {
+			p.sessions++
+			p.created++
+			p.logMetrics("opening new session")
+			p.mutex.Unlock()
+			client, err := p.config.ClientContext(ctx)
+			if err != nil {
+				p.discardClient()
+				return nil, err
+			}
+			return client, nil
+		}
+
+		waiter := make(chan *api.ConvenientClient, 1)
+		p.waiters = append(p.waiters, waiter)
+		p.waited++
+		p.logMetrics("waiting for a free session")
 		p.mutex.Unlock()
-		return client, nil
+
+		select {
+		case client := <-waiter:
+			if client != nil {
+				p.mutex.Lock()
+				p.reused++
+				p.logMetrics("reusing released session")
+				p.mutex.Unlock()
+				if err := keepAlive(ctx, client); err != nil {
+					p.discardClient()
+					return nil, err
+				}
+				return client, nil
+			}
+			// A session slot was released, try again
+			p.mutex.Lock()
+		case <-ctx.Done():
+			p.mutex.Lock()
+			p.removeWaiter(waiter)
+			select {
+			case client := <-waiter:
+				// Handed over while giving up: pass it on
+				p.release(client)
+			default:
+			}
+			p.mutex.Unlock()
+			return nil, ctx.Err()
+		}
 	}
-	p.mutex.Unlock()
-	return p.config.Client()
 }
 
 // Put back a client to the pool.
@@ -73,7 +240,116 @@ func (p *DynProvider) GetClient() (*api.ConvenientClient, error) {
 func (p *DynProvider) PutClient(c *api.ConvenientClient) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
-	p.clients = append(p.clients, c)
+	p.release(c)
+}
+
+// release hands a client over to the first waiter, or puts it back to the
+// idle clients. A nil client releases a session slot. It must be called with
+// p.mutex held.
+func (p *DynProvider) release(c *api.ConvenientClient) {
+	if len(p.waiters) > 0 {
+		waiter := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		waiter <- c
+		return
+	}
+	if c != nil {
+		p.clients = append(p.clients, c)
+	}
+}
+
+// discardClient forgets about a session which could not be opened or renewed.
+func (p *DynProvider) discardClient() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.sessions--
+	p.release(nil)
+}
+
+// logoutClient logs out a session which must not be reused, e.g. holding
+// unpublished changes, and frees its slot.
+func (p *DynProvider) logoutClient(c *api.ConvenientClient) {
+	if err := c.Logout(); err != nil {
+		log.Printf("[WARN] Failed to log out of Dyn session: %s", err)
+	}
+	p.discardClient()
+}
+
+func (p *DynProvider) removeWaiter(waiter chan *api.ConvenientClient) {
+	for i, w := range p.waiters {
+		if w == waiter {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *DynProvider) waitingClients() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.waiters)
+}
+
+func (p *DynProvider) logMetrics(event string) {
+	log.Printf("[DEBUG] Dyn session pool: %s (sessions: %d/%d, created: %d, reused: %d, waited: %d)",
+		event, p.sessions, p.maxSessions, p.created, p.reused, p.waited)
+}
+
+// Close stops the background work of the provider, and logs out every idle
+// session of the pool.
+func (p *DynProvider) Close() {
+	p.cancel()
+
+	p.mutex.Lock()
+	clients := p.clients
+	p.clients = nil
+	p.sessions -= len(clients)
+	p.logMetrics(fmt.Sprintf("logging out %d sessions", len(clients)))
+	p.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *api.ConvenientClient) {
+			defer wg.Done()
+			if err := client.Logout(); err != nil {
+				log.Printf("[WARN] Failed to log out of Dyn session: %s", err)
+			}
+		}(client)
+	}
+	wg.Wait()
+}
+
+// Providers configured by this process, to log out their sessions on
+// shutdown.
+var configuredProviders = struct {
+	sync.Mutex
+	list []*DynProvider
+}{}
+
+// Shutdown logs out the sessions of every configured provider. It is called
+// when the plugin process stops.
+func Shutdown() {
+	configuredProviders.Lock()
+	defer configuredProviders.Unlock()
+	for _, p := range configuredProviders.list {
+		p.Close()
+	}
+	configuredProviders.list = nil
+}
+
+// keepAlive extends the session of a client idle for a while, so that it
+// does not expire before its next use. The client logs in again if it already
+// expired.
+func keepAlive(ctx context.Context, client *api.ConvenientClient) error {
+	if time.Since(client.IdleSince()) < sessionKeepAliveAfter {
+		return nil
+	}
+	log.Printf("[DEBUG] Sending keep-alive for idle Dyn session")
+	if err := client.KeepAliveContext(ctx); err != nil {
+		return fmt.Errorf("Error renewing Dyn session: %s", err)
+	}
+	return nil
 }
 
 func GetProvider(meta interface{}) *DynProvider {
@@ -85,11 +361,27 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		CustomerName: d.Get("customer_name").(string),
 		Username:     d.Get("username").(string),
 		Password:     d.Get("password").(string),
+		Endpoint:     d.Get("endpoint").(string),
+		MaxRetries:   d.Get("max_retries").(int),
+		RetryMaxWait: time.Duration(d.Get("retry_max_wait").(int)) * time.Second,
 	}
 
 	provider := DynProvider{
-		config:  &config,
-		clients: make([]*api.ConvenientClient, 0, 10),
+		config:       &config,
+		clients:      make([]*api.ConvenientClient, 0, 10),
+		maxSessions:  d.Get("max_sessions").(int),
+		publishDelay: zonePublishDelay,
+
+		discardZoneChanges:   d.Get("pending_zone_changes").(string) == "discard",
+		publishNotesTemplate: d.Get("publish_notes").(string),
+
+		stageTrafficDirectorChanges: d.Get("stage_traffic_director_changes").(bool),
 	}
+	provider.ctx, provider.cancel = context.WithCancel(context.Background())
+
+	configuredProviders.Lock()
+	configuredProviders.list = append(configuredProviders.list, &provider)
+	configuredProviders.Unlock()
+
 	return &provider, nil
 }
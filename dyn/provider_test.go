@@ -1,9 +1,13 @@
 package dyn
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -44,3 +48,70 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("DYN_ZONE must be set for acceptance tests. The domain is used to ` and destroy record against.")
 	}
 }
+
+// testDynProvider configures a provider against an in-memory DynECT API, so
+// that resources can be tested without credentials.
+func testDynProvider(t *testing.T, server *dyntest.Server) *DynProvider {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"customer_name": dyntest.Customer,
+		"username":      dyntest.Username,
+		"password":      dyntest.Password,
+		"endpoint":      server.Endpoint(),
+	})
+	meta, err := providerConfigure(d)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider := GetProvider(meta)
+	provider.publishDelay = 10 * time.Millisecond
+	return provider
+}
+
+func TestDynProvider_sessionPool(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	provider.maxSessions = 1
+
+	client, err := provider.GetClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// No session left: give up at the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := provider.GetClientContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for a free session, got %v", err)
+	}
+
+	// The session is handed over once released
+	got := make(chan *api.ConvenientClient)
+	go func() {
+		c, err := provider.GetClient()
+		if err != nil {
+			t.Errorf("err: %s", err)
+		}
+		got <- c
+	}()
+	for provider.waitingClients() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	provider.PutClient(client)
+	if c := <-got; c != client {
+		t.Fatal("expected the released session to be reused")
+	}
+	provider.PutClient(client)
+
+	if n := server.CountRequests("POST", "Session"); n != 1 {
+		t.Fatalf("expected a single session, got %d logins", n)
+	}
+	if provider.created != 1 || provider.reused != 1 || provider.waited != 2 {
+		t.Fatalf("unexpected pool metrics: created %d, reused %d, waited %d", provider.created, provider.reused, provider.waited)
+	}
+
+	provider.Close()
+	if n := server.CountRequests("DELETE", "Session"); n != 1 {
+		t.Fatalf("expected the session to be logged out, got %d logouts", n)
+	}
+}
@@ -0,0 +1,69 @@
+package dyn
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const publishNotesVariables = "`${workspace}` is replaced by the value of the `TF_WORKSPACE` environment variable, or `default`: " +
+	"the workspace picked with `terraform workspace select` is not passed to the provider. " +
+	"`${resource_type}` is replaced by the type of the resource making the change, e.g. `dyn_record`. " +
+	"The address of the resource is not passed to the provider, so it cannot be included. " +
+	"Escape the variables as `$${workspace}` and `$${resource_type}` in the configuration, so that Terraform does not interpolate them."
+
+// publishNotesSchema is the publish_notes attribute of the resources whose
+// changes are published.
+func publishNotesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Description: "Notes sent with the publishes of the changes made by this resource, " +
+			"instead of the `publish_notes` of the provider. " + publishNotesVariables,
+	}
+}
+
+// publishNotes returns the notes to send with the publish of a change made by
+// a resource of type resourceType: its publish_notes, or those of the
+// provider, with their variables replaced.
+func (p *DynProvider) publishNotes(d *schema.ResourceData, resourceType string) string {
+	notes := p.publishNotesTemplate
+	if v, ok := d.GetOk("publish_notes"); ok {
+		notes = v.(string)
+	}
+	workspace := os.Getenv("TF_WORKSPACE")
+	if workspace == "" {
+		workspace = "default"
+	}
+	return strings.NewReplacer(
+		"${workspace}", workspace,
+		"${resource_type}", resourceType,
+	).Replace(notes)
+}
+
+// publishBlock returns the publish block of the Traffic Director requests
+// made by a resource of type resourceType. Creates and updates are left
+// unpublished when stage_traffic_director_changes is set.
+func (p *DynProvider) publishBlock(d *schema.ResourceData, resourceType string) api.PublishBlock {
+	if p.stageTrafficDirectorChanges {
+		return api.PublishBlock{Publish: false}
+	}
+	return api.PublishBlock{
+		Publish: true,
+		Notes:   p.publishNotes(d, resourceType),
+	}
+}
+
+// publishDeleteBlock returns the publish block of the Traffic Director deletes
+// made by a resource of type resourceType. Deletes are published even when
+// stage_traffic_director_changes is set: a deleted object no longer feeds the
+// triggers of a dyn_traffic_director_publish resource, so nothing else would
+// publish them in the same apply.
+func (p *DynProvider) publishDeleteBlock(d *schema.ResourceData, resourceType string) api.PublishBlock {
+	return api.PublishBlock{
+		Publish: true,
+		Notes:   p.publishNotes(d, resourceType),
+	}
+}
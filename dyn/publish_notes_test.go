@@ -0,0 +1,71 @@
+package dyn
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynProvider_publishNotes(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+	provider.publishNotesTemplate = "terraform: ${workspace} ${resource_type}"
+
+	workspace, ok := os.LookupEnv("TF_WORKSPACE")
+	os.Setenv("TF_WORKSPACE", "production")
+	defer func() {
+		if ok {
+			os.Setenv("TF_WORKSPACE", workspace)
+		} else {
+			os.Unsetenv("TF_WORKSPACE")
+		}
+	}()
+
+	record := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":  "example.com",
+		"name":  "www",
+		"type":  "A",
+		"value": "192.168.0.10",
+	})
+	if err := resourceDynRecordCreate(record, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Resources can override the notes of the provider
+	overridden := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":          "example.com",
+		"name":          "mail",
+		"type":          "A",
+		"value":         "192.168.0.11",
+		"publish_notes": "hotfix in ${workspace}",
+	})
+	if err := resourceDynRecordCreate(overridden, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{"terraform: production dyn_record", "hotfix in production"}
+	if notes := server.PublishNotes("example.com"); !reflect.DeepEqual(notes, expected) {
+		t.Fatalf("expected notes %q, got %q", expected, notes)
+	}
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+		"node": []interface{}{
+			map[string]interface{}{"zone": "example.com", "fqdn": "www.example.com"},
+		},
+	}, provider)
+	serviceNotes := server.ServicePublishNotes(td.Id())
+	if len(serviceNotes) == 0 {
+		t.Fatal("expected the service to be published")
+	}
+	for _, notes := range serviceNotes {
+		if notes != "terraform: production dyn_traffic_director" {
+			t.Fatalf("unexpected notes: %q", notes)
+		}
+	}
+}
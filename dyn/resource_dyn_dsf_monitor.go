@@ -2,6 +2,7 @@ package dyn
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -14,6 +15,9 @@ func resourceDynDSFMonitor() *schema.Resource {
 		Read:   resourceDynDSFMonitorRead,
 		Update: resourceDynDSFMonitorUpdate,
 		Delete: resourceDynDSFMonitorDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"label": {
@@ -154,6 +158,11 @@ func resourceDynDSFMonitorRead(d *schema.ResourceData, meta interface{}) error {
 	url := fmt.Sprintf("DSFMonitor/%s", id)
 	err = client.Do("GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director monitor %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
@@ -3,6 +3,7 @@ package dyn
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -16,6 +17,9 @@ func resourceDynDsfRecord() *schema.Resource {
 		ReadContext:   resourceDynDsfRecordRead,
 		UpdateContext: resourceDynDsfRecordUpdate,
 		DeleteContext: resourceDynDsfRecordDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceDynDsfRecordImportState,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"record_set_id": {
@@ -71,12 +75,13 @@ func resourceDynDsfRecord() *schema.Resource {
 				Required:    true,
 				Description: "The value to put in the record, i.e. 1.2.3.4 for a DNS A record",
 			},
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
 func resourceDynDsfRecordCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	request := computeRequest(d)
+	request := computeRequest(d, meta)
 
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	record_set_id := d.Get("record_set_id").(string)
@@ -84,13 +89,13 @@ func resourceDynDsfRecordCreate(ctx context.Context, d *schema.ResourceData, met
 
 	response := &api.DSFRecordResponse{}
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
-	err = client.Do("POST", url, request, response)
+	err = client.DoContext(ctx, "POST", url, request, response)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -103,7 +108,7 @@ func resourceDynDsfRecordCreate(ctx context.Context, d *schema.ResourceData, met
 
 func resourceDynDsfRecordRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -115,8 +120,13 @@ func resourceDynDsfRecordRead(ctx context.Context, d *schema.ResourceData, meta
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	url := fmt.Sprintf("DSFRecord/%s/%s", traffic_director_id, id)
 
-	err = client.Do("GET", url, nil, response)
+	err = client.DoContext(ctx, "GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director record %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(err)
 	}
 
@@ -127,20 +137,20 @@ func resourceDynDsfRecordRead(ctx context.Context, d *schema.ResourceData, meta
 
 func resourceDynDsfRecordUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
-	request := computeRequest(d)
+	request := computeRequest(d, meta)
 	response := &api.DSFRecordResponse{}
 
 	id := d.Id()
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	url := fmt.Sprintf("DSFRecord/%s/%s", traffic_director_id, id)
 
-	err = client.Do("PUT", url, request, response)
+	err = client.DoContext(ctx, "PUT", url, request, response)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -154,17 +164,15 @@ func resourceDynDsfRecordDelete(ctx context.Context, d *schema.ResourceData, met
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	id := d.Id()
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
-	request := api.PublishBlock{
-		Publish: true,
-	}
+	request := provider.publishDeleteBlock(d, "dyn_dsf_record")
 	url := fmt.Sprintf("DSFRecord/%s/%s", traffic_director_id, id)
-	err = client.Do("DELETE", url, &request, nil)
+	err = client.DoContext(ctx, "DELETE", url, &request, nil)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -172,16 +180,14 @@ func resourceDynDsfRecordDelete(ctx context.Context, d *schema.ResourceData, met
 	return nil
 }
 
-func computeRequest(d *schema.ResourceData) *api.DSFRecordRequest {
+func computeRequest(d *schema.ResourceData, meta interface{}) *api.DSFRecordRequest {
 	request := &api.DSFRecordRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label:      d.Get("label").(string),
-		Weight:     d.Get("weight").(int),
-		Automation: d.Get("automation").(string),
-		MasterLine: d.Get("master_line").(string),
-		Eligible:   nil,
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_dsf_record"),
+		Label:        d.Get("label").(string),
+		Weight:       d.Get("weight").(int),
+		Automation:   d.Get("automation").(string),
+		MasterLine:   d.Get("master_line").(string),
+		Eligible:     nil,
 	}
 	if request.Automation == "manual" {
 		eligible := api.SBool(d.Get("eligible").(bool))
@@ -192,6 +198,9 @@ func computeRequest(d *schema.ResourceData) *api.DSFRecordRequest {
 
 func load_dsf_record(d *schema.ResourceData, response *api.DSFRecord) {
 	d.Set("label", response.Label)
+	if response.DSFRecordSetID != "" {
+		d.Set("record_set_id", response.DSFRecordSetID)
+	}
 	d.Set("weight", response.Weight)
 	d.Set("automation", response.Automation)
 	d.Set("master_line", response.MasterLine)
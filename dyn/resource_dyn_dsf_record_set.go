@@ -3,6 +3,7 @@ package dyn
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -16,6 +17,9 @@ func resourceDynDSFRecordSet() *schema.Resource {
 		ReadContext:   resourceDynDSFRecordSetRead,
 		UpdateContext: resourceDynDSFRecordSetUpdate,
 		DeleteContext: resourceDynDSFRecordSetDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceDynDSFRecordSetImportState,
+		},
 
 		Description: "Dynect traffic director record set",
 		Schema: map[string]*schema.Schema{
@@ -99,24 +103,25 @@ func resourceDynDSFRecordSet() *schema.Resource {
 				Optional:    true,
 				Description: "The id of the monitoring object",
 			},
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
 func resourceDynDSFRecordSetCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	request := computeDSFRecordSetRequest(d, true)
+	request := computeDSFRecordSetRequest(d, meta, true)
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	response := &api.DSFRecordSetResponse{}
 
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
 	url := fmt.Sprintf("DSFRecordSet/%s", traffic_director_id)
-	err = client.Do("POST", url, request, response)
+	err = client.DoContext(ctx, "POST", url, request, response)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -132,7 +137,7 @@ func resourceDynDSFRecordSetRead(ctx context.Context, d *schema.ResourceData, me
 	id := d.Id()
 
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -141,8 +146,13 @@ func resourceDynDSFRecordSetRead(ctx context.Context, d *schema.ResourceData, me
 	response := &api.DSFRecordSetResponse{}
 
 	url := fmt.Sprintf("DSFRecordSet/%s/%s", traffic_director_id, id)
-	err = client.Do("GET", url, nil, response)
+	err = client.DoContext(ctx, "GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director record set %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(err)
 	}
 
@@ -156,17 +166,17 @@ func resourceDynDSFRecordSetUpdate(ctx context.Context, d *schema.ResourceData,
 	id := d.Id()
 
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
-	request := computeDSFRecordSetRequest(d, false)
+	request := computeDSFRecordSetRequest(d, meta, false)
 	response := &api.DSFRecordSetResponse{}
 
 	url := fmt.Sprintf("DSFRecordSet/%s/%s", traffic_director_id, id)
-	err = client.Do("PUT", url, request, response)
+	err = client.DoContext(ctx, "PUT", url, request, response)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -179,18 +189,16 @@ func resourceDynDSFRecordSetUpdate(ctx context.Context, d *schema.ResourceData,
 func resourceDynDSFRecordSetDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	id := d.Id()
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
+	client, err := provider.GetClientContext(ctx)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	defer provider.PutClient(client)
 
 	traffic_director_id := d.Get("traffic_director_id").(string)
-	publish := api.PublishBlock{
-		Publish: true,
-	}
+	publish := provider.publishDeleteBlock(d, "dyn_dsf_record_set")
 	url := fmt.Sprintf("DSFRecordSet/%s/%s", traffic_director_id, id)
-	err = client.Do("DELETE", url, publish, nil)
+	err = client.DoContext(ctx, "DELETE", url, publish, nil)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -198,11 +206,9 @@ func resourceDynDSFRecordSetDelete(ctx context.Context, d *schema.ResourceData,
 	return nil
 }
 
-func computeDSFRecordSetRequest(d *schema.ResourceData, isCreate bool) *api.DSFRecordSetRequest {
+func computeDSFRecordSetRequest(d *schema.ResourceData, meta interface{}, isCreate bool) *api.DSFRecordSetRequest {
 	request := &api.DSFRecordSetRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_dsf_record_set"),
 		Label:        d.Get("label").(string),
 		TTL:          api.SInt(d.Get("ttl").(int)),
 		Automation:   d.Get("automation").(string),
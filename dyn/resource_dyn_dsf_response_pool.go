@@ -2,6 +2,7 @@ package dyn
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -14,6 +15,9 @@ func resourceDynDSFResponsePool() *schema.Resource {
 		Read:   resourceDynDSFResponsePoolRead,
 		Update: resourceDynDSFResponsePoolUpdate,
 		Delete: resourceDynDSFResponsePoolDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceDynDSFResponsePoolImportState,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"label": {
@@ -36,17 +40,16 @@ func resourceDynDSFResponsePool() *schema.Resource {
 				ForceNew:    true,
 				Description: "Traffic director id to attach this response pool",
 			},
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
 func resourceDynDSFResponsePoolCreate(d *schema.ResourceData, meta interface{}) error {
 	request := &api.DSFResponsePoolRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label:      d.Get("label").(string),
-		Automation: d.Get("automation").(string),
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_dsf_response_pool"),
+		Label:        d.Get("label").(string),
+		Automation:   d.Get("automation").(string),
 	}
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	response := &api.DSFResponsePoolResponse{}
@@ -86,6 +89,11 @@ func resourceDynDSFResponsePoolRead(d *schema.ResourceData, meta interface{}) er
 	url := fmt.Sprintf("DSFResponsePool/%s/%s", traffic_director_id, id)
 	err = client.Do("GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director response pool %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
@@ -106,11 +114,9 @@ func resourceDynDSFResponsePoolUpdate(d *schema.ResourceData, meta interface{})
 	defer provider.PutClient(client)
 
 	request := &api.DSFResponsePoolRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label:      d.Get("label").(string),
-		Automation: d.Get("automation").(string),
+		PublishBlock: provider.publishBlock(d, "dyn_dsf_response_pool"),
+		Label:        d.Get("label").(string),
+		Automation:   d.Get("automation").(string),
 	}
 	response := &api.DSFResponsePoolResponse{}
 
@@ -136,9 +142,7 @@ func resourceDynDSFResponsePoolDelete(d *schema.ResourceData, meta interface{})
 	defer provider.PutClient(client)
 
 	traffic_director_id := d.Get("traffic_director_id").(string)
-	publish := api.PublishBlock{
-		Publish: true,
-	}
+	publish := provider.publishDeleteBlock(d, "dyn_dsf_response_pool")
 	url := fmt.Sprintf("DSFResponsePool/%s/%s", traffic_director_id, id)
 	err = client.Do("DELETE", url, publish, nil)
 	if err != nil {
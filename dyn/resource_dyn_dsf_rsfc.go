@@ -2,6 +2,7 @@ package dyn
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -13,6 +14,9 @@ func resourceDynDSFRsfc() *schema.Resource {
 		Read:   resourceDynDSFRsfcRead,
 		Update: resourceDynDSFRsfcUpdate,
 		Delete: resourceDynDSFRsfcDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceDynDSFRsfcImportState,
+		},
 
 		Description: "Dynect RecordSet Failover Chain",
 		Schema: map[string]*schema.Schema{
@@ -33,16 +37,15 @@ func resourceDynDSFRsfc() *schema.Resource {
 				ForceNew:    true,
 				Description: "The response pool id in which we create the ressource",
 			},
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
 func resourceDynDSFRsfcCreate(d *schema.ResourceData, meta interface{}) error {
 	request := &api.DSFRsfcRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label: d.Get("label").(string),
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_dsf_rsfc"),
+		Label:        d.Get("label").(string),
 	}
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	response_pool_id := d.Get("response_pool_id").(string)
@@ -83,6 +86,11 @@ func resourceDynDSFRsfcRead(d *schema.ResourceData, meta interface{}) error {
 	url := fmt.Sprintf("DSFRecordSetFailoverChain/%s/%s", traffic_director_id, id)
 	err = client.Do("GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director record set failover chain %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
@@ -103,10 +111,8 @@ func resourceDynDSFRsfcUpdate(d *schema.ResourceData, meta interface{}) error {
 	defer provider.PutClient(client)
 
 	request := &api.DSFRsfcRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label: d.Get("label").(string),
+		PublishBlock: provider.publishBlock(d, "dyn_dsf_rsfc"),
+		Label:        d.Get("label").(string),
 	}
 	response := &api.DSFRsfcResponse{}
 
@@ -132,9 +138,7 @@ func resourceDynDSFRsfcDelete(d *schema.ResourceData, meta interface{}) error {
 	defer provider.PutClient(client)
 
 	traffic_director_id := d.Get("traffic_director_id").(string)
-	publish := api.PublishBlock{
-		Publish: true,
-	}
+	publish := provider.publishDeleteBlock(d, "dyn_dsf_rsfc")
 	url := fmt.Sprintf("DSFRecordSetFailoverChain/%s/%s", traffic_director_id, id)
 	err = client.Do("DELETE", url, publish, nil)
 	if err != nil {
@@ -146,4 +150,7 @@ func resourceDynDSFRsfcDelete(d *schema.ResourceData, meta interface{}) error {
 
 func load_dsf_rsfc(d *schema.ResourceData, response *api.DSFRecordSetChain) {
 	d.Set("label", response.Label)
+	if response.DSFResponsePoolID != "" {
+		d.Set("response_pool_id", response.DSFResponsePoolID)
+	}
 }
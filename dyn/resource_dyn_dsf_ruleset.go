@@ -1,10 +1,14 @@
 package dyn
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"strconv"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceDynDSFRuleset() *schema.Resource {
@@ -13,6 +17,11 @@ func resourceDynDSFRuleset() *schema.Resource {
 		Read:   resourceDynDSFRulesetRead,
 		Update: resourceDynDSFRulesetUpdate,
 		Delete: resourceDynDSFRulesetDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceDynDSFRulesetImportState,
+		},
+
+		CustomizeDiff: resourceDynDSFRulesetCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"label": {
@@ -35,17 +44,39 @@ func resourceDynDSFRuleset() *schema.Resource {
 					Description: "ID of a response pool",
 				},
 			},
+			"ordering": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description: "Position of the Ruleset in the evaluation order of the traffic director, starting at 0. Rulesets are evaluated in order and the first matching one serves the client, so a catch-all `always` ruleset should come last. Defaults to after the existing rulesets. " +
+					"The position is absolute: creating or deleting a ruleset before this one shifts it, showing a diff. " +
+					"Use a `dyn_traffic_director_ruleset_order` resource instead to order several rulesets.",
+			},
+			"criteria_type": dsfCriteriaTypeSchema(),
+			"geoip":         dsfGeoIPSchema(dsfGeoIPAttributes),
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
+var dsfGeoIPAttributes = []string{"geoip.0.region", "geoip.0.country", "geoip.0.province"}
+
+// resourceDynDSFRulesetCustomizeDiff checks that the geoip block is set on
+// geoip rulesets only.
+func resourceDynDSFRulesetCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	criteriaType := d.Get("criteria_type").(string)
+	geoip := d.Get("geoip").([]interface{})
+	return checkDSFCriteria("", criteriaType, geoip, d.NewValueKnown("geoip"))
+}
+
 func resourceDynDSFRulesetCreate(d *schema.ResourceData, meta interface{}) error {
 	request := &api.DSFRulesetRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_dsf_ruleset"),
 		Label:        d.Get("label").(string),
-		CriteriaType: "always",
+		CriteriaType: d.Get("criteria_type").(string),
+		Criteria:     expandDSFCriteria(d.Get("geoip").([]interface{})),
+		Ordering:     computeRulesetOrdering(d),
 		ResponsePool: computRuleSetResponsePool(d),
 	}
 	traffic_director_id := d.Get("traffic_director_id").(string)
@@ -82,6 +113,15 @@ func computRuleSetResponsePool(d *schema.ResourceData) *[]api.DSFResponsePoolRef
 	return &pool
 }
 
+// computeRulesetOrdering returns the position requested for the ruleset, or
+// an empty string to let Dyn append it.
+func computeRulesetOrdering(d *schema.ResourceData) string {
+	if v, ok := d.GetOkExists("ordering"); ok {
+		return strconv.Itoa(v.(int))
+	}
+	return ""
+}
+
 func resourceDynDSFRulesetRead(d *schema.ResourceData, meta interface{}) error {
 	traffic_director_id := d.Get("traffic_director_id").(string)
 	id := d.Id()
@@ -98,6 +138,11 @@ func resourceDynDSFRulesetRead(d *schema.ResourceData, meta interface{}) error {
 	url := fmt.Sprintf("DSFRuleset/%s/%s", traffic_director_id, id)
 	err = client.Do("GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director ruleset %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
@@ -118,13 +163,17 @@ func resourceDynDSFRulesetUpdate(d *schema.ResourceData, meta interface{}) error
 	defer provider.PutClient(client)
 
 	request := &api.DSFRulesetRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
+		PublishBlock: provider.publishBlock(d, "dyn_dsf_ruleset"),
 		Label:        d.Get("label").(string),
-		CriteriaType: "always",
+		CriteriaType: d.Get("criteria_type").(string),
+		Criteria:     expandDSFCriteria(d.Get("geoip").([]interface{})),
 		ResponsePool: computRuleSetResponsePool(d),
 	}
+	// The ordering read back is shifted by the creation and deletion of the
+	// other rulesets, so it is only sent when changed in the configuration
+	if d.HasChange("ordering") {
+		request.Ordering = computeRulesetOrdering(d)
+	}
 	response := &api.DSFRulesetResponse{}
 
 	url := fmt.Sprintf("DSFRuleset/%s/%s", traffic_director_id, id)
@@ -148,9 +197,7 @@ func resourceDynDSFRulesetDelete(d *schema.ResourceData, meta interface{}) error
 	defer provider.PutClient(client)
 
 	traffic_director_id := d.Get("traffic_director_id").(string)
-	publish := api.PublishBlock{
-		Publish: true,
-	}
+	publish := provider.publishDeleteBlock(d, "dyn_dsf_ruleset")
 	url := fmt.Sprintf("DSFRuleset/%s/%s", traffic_director_id, id)
 	err = client.Do("DELETE", url, publish, nil)
 	if err != nil {
@@ -160,6 +207,20 @@ func resourceDynDSFRulesetDelete(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
+func flattenDSFResponsePoolIDs(pools []api.DSFResponsePool) []interface{} {
+	ids := make([]interface{}, len(pools))
+	for i, pool := range pools {
+		ids[i] = pool.ID
+	}
+	return ids
+}
+
 func load_dsf_ruleset(d *schema.ResourceData, response *api.DSFRuleset) {
 	d.Set("label", response.Label)
+	d.Set("criteria_type", response.CriteriaType)
+	d.Set("response_pool_ids", flattenDSFResponsePoolIDs(response.ResponsePools))
+	d.Set("geoip", flattenDSFCriteria(&response.Criteria))
+	if ordering, err := strconv.Atoi(response.Ordering); err == nil {
+		d.Set("ordering", ordering)
+	}
 }
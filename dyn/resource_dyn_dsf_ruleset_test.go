@@ -0,0 +1,252 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDynDSFRuleset_geoip(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+
+	ruleset := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "europe",
+		"traffic_director_id": td.Id(),
+		"criteria_type":       "geoip",
+		"geoip": []interface{}{map[string]interface{}{
+			"region":  []interface{}{"15", "16"},
+			"country": []interface{}{"FR"},
+		}},
+	})
+	if err := resourceDynDSFRulesetCreate(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	read := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"traffic_director_id": td.Id(),
+	})
+	read.SetId(ruleset.Id())
+	if err := resourceDynDSFRulesetRead(read, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if criteriaType := read.Get("criteria_type").(string); criteriaType != "geoip" {
+		t.Fatalf("unexpected criteria type: %s", criteriaType)
+	}
+	if n := read.Get("geoip.0.region").(*schema.Set).Len(); n != 2 {
+		t.Fatalf("expected 2 regions, got %d", n)
+	}
+	if !read.Get("geoip.0.country").(*schema.Set).Contains("FR") {
+		t.Fatal("expected the country to be read back")
+	}
+
+	// Back to a catch-all ruleset
+	ruleset.Set("criteria_type", "always")
+	ruleset.Set("geoip", []interface{}{})
+	if err := resourceDynDSFRulesetUpdate(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFRulesetRead(read, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := len(read.Get("geoip").([]interface{})); read.Get("criteria_type") != "always" || n != 0 {
+		t.Fatalf("expected the geoip criteria to be removed, got %d blocks", n)
+	}
+}
+
+func TestDynDSFRuleset_validateGeoIP(t *testing.T) {
+	cases := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{
+				"label": "europe", "traffic_director_id": "td", "criteria_type": "geoip",
+				"geoip": []interface{}{map[string]interface{}{"country": []interface{}{"XX"}}},
+			},
+			"geoip.0.country",
+		},
+		{
+			map[string]interface{}{
+				"label": "europe", "traffic_director_id": "td", "criteria_type": "geoip",
+				"geoip": []interface{}{map[string]interface{}{"region": []interface{}{"99"}}},
+			},
+			"geoip.0.region",
+		},
+	}
+	for _, tc := range cases {
+		diags := resourceDynDSFRuleset().Validate(terraform.NewResourceConfigRaw(tc.config))
+		if !diags.HasError() {
+			t.Errorf("expected %v to be invalid", tc.config)
+			continue
+		}
+		if message := fmt.Sprintf("%v", diags); !strings.Contains(message, tc.expected) {
+			t.Errorf("expected an error about %s, got %s", tc.expected, message)
+		}
+	}
+
+	diffCases := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{"label": "europe", "traffic_director_id": "td", "criteria_type": "geoip"},
+			"geoip: block is required",
+		},
+		{
+			map[string]interface{}{
+				"label": "all", "traffic_director_id": "td",
+				"geoip": []interface{}{map[string]interface{}{"country": []interface{}{"FR"}}},
+			},
+			"geoip: block can only be set when criteria_type is geoip",
+		},
+	}
+	for _, tc := range diffCases {
+		_, err := resourceDynDSFRuleset().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.config), nil)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("expected an error about %s, got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestDynDSFRuleset_ordering(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+
+	catchAll := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "all",
+		"traffic_director_id": td.Id(),
+	})
+	if err := resourceDynDSFRulesetCreate(catchAll, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ordering := catchAll.Get("ordering").(int); ordering != 0 {
+		t.Fatalf("expected the first ruleset to be at 0, got %d", ordering)
+	}
+
+	// A geoip ruleset inserted before the catch-all one
+	europe := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "europe",
+		"traffic_director_id": td.Id(),
+		"ordering":            0,
+		"criteria_type":       "geoip",
+		"geoip": []interface{}{map[string]interface{}{
+			"region": []interface{}{"15"},
+		}},
+	})
+	if err := resourceDynDSFRulesetCreate(europe, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ordering := europe.Get("ordering").(int); ordering != 0 {
+		t.Fatalf("expected the geoip ruleset to be at 0, got %d", ordering)
+	}
+	if err := resourceDynDSFRulesetRead(catchAll, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ordering := catchAll.Get("ordering").(int); ordering != 1 {
+		t.Fatalf("expected the catch-all ruleset to be moved to 1, got %d", ordering)
+	}
+
+	// Moving the catch-all ruleset back first
+	catchAll = testPlanResource(t, resourceDynDSFRuleset(), catchAll, map[string]interface{}{
+		"label":               "all",
+		"traffic_director_id": td.Id(),
+		"ordering":            0,
+	}, provider)
+	if err := resourceDynDSFRulesetUpdate(catchAll, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFRulesetRead(europe, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ordering := europe.Get("ordering").(int); ordering != 1 {
+		t.Fatalf("expected the geoip ruleset to be moved to 1, got %d", ordering)
+	}
+}
+
+func TestDynDSFRuleset_import(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+	var poolIDs []interface{}
+	for _, label := range []string{"primary", "secondary"} {
+		pool := schema.TestResourceDataRaw(t, resourceDynDSFResponsePool().Schema, map[string]interface{}{
+			"label":               label,
+			"automation":          "auto",
+			"traffic_director_id": td.Id(),
+		})
+		if err := resourceDynDSFResponsePoolCreate(pool, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		poolIDs = append(poolIDs, pool.Id())
+	}
+
+	ruleset := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "all",
+		"traffic_director_id": td.Id(),
+		"response_pool_ids":   poolIDs,
+	})
+	if err := resourceDynDSFRulesetCreate(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	imported := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{})
+	imported.SetId(fmt.Sprintf("%s/%s", td.Id(), ruleset.Id()))
+	results, err := resourceDynDSFRulesetImportState(imported, provider)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	imported = results[0]
+	if err := resourceDynDSFRulesetRead(imported, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if imported.Id() != ruleset.Id() || imported.Get("traffic_director_id") != td.Id() {
+		t.Fatalf("unexpected import: %s in %s", imported.Id(), imported.Get("traffic_director_id"))
+	}
+	if label := imported.Get("label").(string); label != "all" {
+		t.Fatalf("unexpected label: %s", label)
+	}
+	if ids := imported.Get("response_pool_ids").([]interface{}); !reflect.DeepEqual(ids, poolIDs) {
+		t.Fatalf("expected the response pools %v, got %v", poolIDs, ids)
+	}
+
+	// Detaching a response pool outside of Terraform shows as drift
+	imported.Set("response_pool_ids", poolIDs[1:])
+	if err := resourceDynDSFRulesetUpdate(imported, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFRulesetRead(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ids := ruleset.Get("response_pool_ids").([]interface{}); !reflect.DeepEqual(ids, poolIDs[1:]) {
+		t.Fatalf("expected the response pools %v, got %v", poolIDs[1:], ids)
+	}
+
+	for _, id := range []string{ruleset.Id(), "/" + ruleset.Id(), td.Id() + "/" + ruleset.Id() + "/1"} {
+		invalid := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{})
+		invalid.SetId(id)
+		if _, err := resourceDynDSFRulesetImportState(invalid, provider); err == nil {
+			t.Errorf("expected the import of %s to fail", id)
+		}
+	}
+}
@@ -1,17 +1,18 @@
 package dyn
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-var mutex = &sync.Mutex{}
-
 func resourceDynRecord() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceDynRecordCreate,
@@ -21,6 +22,7 @@ func resourceDynRecord() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: resourceDynRecordImportState,
 		},
+		CustomizeDiff: resourceDynRecordCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"zone": {
@@ -30,18 +32,10 @@ func resourceDynRecord() *schema.Resource {
 			},
 
 			"name": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
-				DiffSuppressFunc: func(k, oldV, newV string, d *schema.ResourceData) bool {
-					// Records for top level domain
-					zone := d.Get("zone").(string)
-					if oldV == zone && newV == "" {
-						return true
-					}
-
-					return oldV == newV
-				},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressZoneApexName,
 			},
 
 			"fqdn": {
@@ -50,17 +44,22 @@ func resourceDynRecord() *schema.Resource {
 			},
 
 			"type": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(api.RecordTypes(), false),
+				Description:  "Type of the record, e.g. `A` or `SRV`.",
 			},
 
 			"value": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: recordValueAttributes,
+				Description:  recordValueDescription(),
 				DiffSuppressFunc: func(k, oldV, newV string, d *schema.ResourceData) bool {
 					recordType := d.Get("type").(string)
-					if recordType == "CNAME" || recordType == "NS" || recordType == "MX" {
+					if recordNameTypes[recordType] {
 						// We expect FQDN here, which may or may not have a trailing dot
 						if !strings.HasSuffix(oldV, ".") {
 							oldV += "."
@@ -79,19 +78,234 @@ func resourceDynRecord() *schema.Resource {
 				Optional: true,
 				Computed: true,
 			},
+
+			"mx": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: recordValueAttributes,
+				Description:  "Data of an `MX` record, instead of `value`.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"preference": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 65535),
+							Description:  "Preference of the mail exchanger, lower values being preferred.",
+						},
+						"exchange": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+							Description:  "Domain name of the mail exchanger.",
+						},
+					},
+				},
+			},
+
+			"srv": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: recordValueAttributes,
+				Description:  "Data of an `SRV` record, instead of `value`.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"priority": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 65535),
+							Description:  "Priority of the target, lower values being preferred.",
+						},
+						"weight": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 65535),
+							Description:  "Relative weight of the target among the targets of the same priority.",
+						},
+						"port": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 65535),
+							Description:  "Port of the service on the target.",
+						},
+						"target": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotWhiteSpace,
+							Description:  "Domain name of the target host.",
+						},
+					},
+				},
+			},
+
+			"caa": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: recordValueAttributes,
+				Description:  "Data of a `CAA` record, instead of `value`.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"flags": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntBetween(0, 255),
+							Description:  "Flags of the record, `128` marking it critical. Defaults to `0`.",
+						},
+						"tag": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[a-zA-Z0-9]+$`), "must be alphanumeric, e.g. issue, issuewild or iodef"),
+							Description:  "Property of the record, e.g. `issue`, `issuewild` or `iodef`.",
+						},
+						"value": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
+							Description:  "Value of the property, e.g. the domain of a certificate authority.",
+						},
+					},
+				},
+			},
+
+			"publish_notes": publishNotesSchema(),
 		},
 	}
 }
 
-func resourceDynRecordCreate(d *schema.ResourceData, meta interface{}) error {
-	mutex.Lock()
+// Attributes holding the data of a record, exactly one of which must be set
+var recordValueAttributes = []string{"value", "mx", "srv", "caa"}
 
-	provider := GetProvider(meta)
-	client, err := provider.GetClient()
-	if err != nil {
-		return err
+// Blocks holding the data of a record, by record type
+var recordDataBlocks = map[string]string{
+	"MX":  "mx",
+	"SRV": "srv",
+	"CAA": "caa",
+}
+
+func resourceDynRecordCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	recordType := d.Get("type").(string)
+	for t, block := range recordDataBlocks {
+		if t != recordType && len(d.Get(block).([]interface{})) > 0 {
+			return fmt.Errorf("%s: block can only be set on %s records, not on %s records", block, t, recordType)
+		}
 	}
-	defer provider.PutClient(client)
+
+	// value is computed from the block when the data is set by a block
+	if block, ok := recordDataBlocks[recordType]; ok && d.HasChange(block) && len(d.Get(block).([]interface{})) > 0 {
+		return d.SetNewComputed("value")
+	}
+	return nil
+}
+
+// expandRecordData returns the data of the record set by a block, or nil when
+// it is set by value.
+func expandRecordData(d *schema.ResourceData) *api.DataBlock {
+	block, ok := recordDataBlocks[d.Get("type").(string)]
+	if !ok {
+		return nil
+	}
+	list := d.Get(block).([]interface{})
+	if len(list) == 0 || list[0] == nil {
+		return nil
+	}
+	m := list[0].(map[string]interface{})
+
+	switch block {
+	case "mx":
+		return &api.DataBlock{
+			Preference: api.FlexString(strconv.Itoa(m["preference"].(int))),
+			Exchange:   m["exchange"].(string),
+		}
+	case "srv":
+		return &api.DataBlock{
+			Priority: api.FlexString(strconv.Itoa(m["priority"].(int))),
+			Weight:   api.FlexString(strconv.Itoa(m["weight"].(int))),
+			Port:     api.FlexString(strconv.Itoa(m["port"].(int))),
+			Target:   m["target"].(string),
+		}
+	case "caa":
+		return &api.DataBlock{
+			Flags: api.FlexString(strconv.Itoa(m["flags"].(int))),
+			Tag:   api.FlexString(m["tag"].(string)),
+			Value: m["value"].(string),
+		}
+	}
+	return nil
+}
+
+// flattenRecordData returns the block holding the data of a record.
+func flattenRecordData(block string, rdata *api.DataBlock) []interface{} {
+	var m map[string]interface{}
+	switch block {
+	case "mx":
+		preference, _ := strconv.Atoi(string(rdata.Preference))
+		m = map[string]interface{}{
+			"preference": preference,
+			"exchange":   rdata.Exchange,
+		}
+	case "srv":
+		priority, _ := strconv.Atoi(string(rdata.Priority))
+		weight, _ := strconv.Atoi(string(rdata.Weight))
+		port, _ := strconv.Atoi(string(rdata.Port))
+		m = map[string]interface{}{
+			"priority": priority,
+			"weight":   weight,
+			"port":     port,
+			"target":   rdata.Target,
+		}
+	case "caa":
+		flags, _ := strconv.Atoi(string(rdata.Flags))
+		m = map[string]interface{}{
+			"flags": flags,
+			"tag":   string(rdata.Tag),
+			"value": rdata.Value,
+		}
+	}
+	return []interface{}{m}
+}
+
+// suppressZoneApexName ignores the name of records for the top level domain,
+// which may be written either empty or as the zone name.
+func suppressZoneApexName(k, oldV, newV string, d *schema.ResourceData) bool {
+	zone := d.Get("zone").(string)
+	if (oldV == zone && newV == "") || (oldV == "" && newV == zone) {
+		return true
+	}
+
+	return oldV == newV
+}
+
+// Types of records whose value ends with a domain name, which may or may not
+// have a trailing dot
+var recordNameTypes = map[string]bool{
+	"ALIAS": true,
+	"CNAME": true,
+	"DNAME": true,
+	"KX":    true,
+	"MX":    true,
+	"NS":    true,
+	"PTR":   true,
+	"SRV":   true,
+}
+
+// recordValueDescription documents the format of the value of each record
+// type.
+func recordValueDescription() string {
+	var b strings.Builder
+	b.WriteString("Value of the record, in the zone file format of its type without the owner, TTL, class and type. " +
+		"The value of `TXT` and `SPF` records is their text as is, and the value of `SOA` records is the email of the zone's contact. " +
+		"Base64 and hexadecimal data is written without spaces. Formats by type:\n")
+	for _, t := range api.RecordTypes() {
+		fmt.Fprintf(&b, "  - `%s`: `%s`\n", t, api.RDataSyntax(t))
+	}
+	return b.String()
+}
+
+func resourceDynRecordCreate(d *schema.ResourceData, meta interface{}) error {
+	provider := GetProvider(meta)
 
 	record := &api.Record{
 		Name:  d.Get("name").(string),
@@ -99,39 +313,41 @@ func resourceDynRecordCreate(d *schema.ResourceData, meta interface{}) error {
 		Type:  d.Get("type").(string),
 		TTL:   d.Get("ttl").(string),
 		Value: d.Get("value").(string),
+		RData: expandRecordData(d),
 	}
 	log.Printf("[DEBUG] Dyn record create configuration: %#v", record)
 
-	// create the record
-	err = client.CreateRecord(record)
+	// create the record, and publish it with the other changes to the zone
+	err := provider.changeZone(context.Background(), record.Zone, provider.publishNotes(d, "dyn_record"), func(client *api.ConvenientClient) error {
+		if err := client.CreateRecord(record); err != nil {
+			return fmt.Errorf("Failed to create Dyn record: %s", err)
+		}
+		return nil
+	})
 	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("Failed to create Dyn record: %s", err)
+		return err
 	}
 
-	// publish the zone
-	err = client.PublishZone(record.Zone)
+	client, err := provider.GetClient()
 	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("Failed to publish Dyn zone: %s", err)
+		return err
 	}
+	defer provider.PutClient(client)
 
-	// get the record ID
-	err = client.GetRecordID(record)
-	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("%s", err)
+	// get the record ID, if the API did not return it
+	if record.ID == "" {
+		err = client.GetRecordID(record)
+		if err != nil {
+			return fmt.Errorf("%s", err)
+		}
 	}
 	d.SetId(record.ID)
+	d.Set("fqdn", record.FQDN)
 
-	mutex.Unlock()
-	return resourceDynRecordRead(d, meta)
+	return readDynRecord(d, client)
 }
 
 func resourceDynRecordRead(d *schema.ResourceData, meta interface{}) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	provider := GetProvider(meta)
 	client, err := provider.GetClient()
 	if err != nil {
@@ -139,6 +355,10 @@ func resourceDynRecordRead(d *schema.ResourceData, meta interface{}) error {
 	}
 	defer provider.PutClient(client)
 
+	return readDynRecord(d, client)
+}
+
+func readDynRecord(d *schema.ResourceData, client *api.ConvenientClient) error {
 	record := &api.Record{
 		ID:   d.Id(),
 		Name: d.Get("name").(string),
@@ -148,8 +368,13 @@ func resourceDynRecordRead(d *schema.ResourceData, meta interface{}) error {
 		Type: d.Get("type").(string),
 	}
 
-	err = client.GetRecord(record)
+	err := client.GetRecord(record)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn record %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return fmt.Errorf("Couldn't find Dyn record: %s", err)
 	}
 
@@ -160,18 +385,16 @@ func resourceDynRecordRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("ttl", record.TTL)
 	d.Set("value", record.Value)
 
+	// Only read back the block of records managed with one
+	if block, ok := recordDataBlocks[record.Type]; ok && record.RData != nil && len(d.Get(block).([]interface{})) > 0 {
+		d.Set(block, flattenRecordData(block, record.RData))
+	}
+
 	return nil
 }
 
 func resourceDynRecordUpdate(d *schema.ResourceData, meta interface{}) error {
-	mutex.Lock()
-
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
-	if err != nil {
-		return err
-	}
-	defer provider.PutClient(client)
 
 	record := &api.Record{
 		ID:    d.Id(),
@@ -180,45 +403,35 @@ func resourceDynRecordUpdate(d *schema.ResourceData, meta interface{}) error {
 		TTL:   d.Get("ttl").(string),
 		Type:  d.Get("type").(string),
 		Value: d.Get("value").(string),
+		RData: expandRecordData(d),
 	}
 	log.Printf("[DEBUG] Dyn record update configuration: %#v", record)
 
-	// update the record
-	err = client.UpdateRecord(record)
+	// update the record, and publish it with the other changes to the zone
+	err := provider.changeZone(context.Background(), record.Zone, provider.publishNotes(d, "dyn_record"), func(client *api.ConvenientClient) error {
+		if err := client.UpdateRecord(record); err != nil {
+			return fmt.Errorf("Failed to update Dyn record: %s", err)
+		}
+		return nil
+	})
 	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("Failed to update Dyn record: %s", err)
+		return err
 	}
 
-	// publish the zone
-	err = client.PublishZone(record.Zone)
-	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("Failed to publish Dyn zone: %s", err)
-	}
+	// the API returns the ID of the updated record
+	d.SetId(record.ID)
 
-	// get the record ID
-	err = client.GetRecordID(record)
+	client, err := provider.GetClient()
 	if err != nil {
-		mutex.Unlock()
-		return fmt.Errorf("%s", err)
+		return err
 	}
-	d.SetId(record.ID)
+	defer provider.PutClient(client)
 
-	mutex.Unlock()
-	return resourceDynRecordRead(d, meta)
+	return readDynRecord(d, client)
 }
 
 func resourceDynRecordDelete(d *schema.ResourceData, meta interface{}) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	provider := GetProvider(meta)
-	client, err := provider.GetClient()
-	if err != nil {
-		return err
-	}
-	defer provider.PutClient(client)
 
 	record := &api.Record{
 		ID:   d.Id(),
@@ -230,17 +443,11 @@ func resourceDynRecordDelete(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] Deleting Dyn record: %s, %s", record.FQDN, record.ID)
 
-	// delete the record
-	err = client.DeleteRecord(record)
-	if err != nil {
-		return fmt.Errorf("Failed to delete Dyn record: %s", err)
-	}
-
-	// publish the zone
-	err = client.PublishZone(record.Zone)
-	if err != nil {
-		return fmt.Errorf("Failed to publish Dyn zone: %s", err)
-	}
-
-	return nil
+	// delete the record, and publish it with the other changes to the zone
+	return provider.changeZone(context.Background(), record.Zone, provider.publishNotes(d, "dyn_record"), func(client *api.ConvenientClient) error {
+		if err := client.DeleteRecord(record); err != nil {
+			return fmt.Errorf("Failed to delete Dyn record: %s", err)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,232 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDynRecordSet() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages all the records of a type at a name, e.g. the A records of a name served in round-robin. " +
+			"Records of the type at the name which are not listed in `values` are deleted.",
+
+		Create: resourceDynRecordSetCreate,
+		Read:   resourceDynRecordSetRead,
+		Update: resourceDynRecordSetUpdate,
+		Delete: resourceDynRecordSetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the zone.",
+			},
+
+			"name": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressZoneApexName,
+				Description:      "Name of the records in the zone. Leave it out for the apex of the zone.",
+			},
+
+			"fqdn": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Fully qualified domain name of the records.",
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(api.RecordTypes(), false),
+				Description:  "Type of the records, e.g. `A` or `AAAA`.",
+			},
+
+			"values": {
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Values of the records, in the format documented on `dyn_record`. Domain names may be written with or without their trailing dot.",
+			},
+
+			"ttl": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "TTL of the records.",
+			},
+
+			"publish_notes": publishNotesSchema(),
+		},
+	}
+}
+
+// The ID of a record set is {type}/{zone}/{fqdn}
+func recordSetID(rs *api.RecordSet) string {
+	return fmt.Sprintf("%s/%s/%s", rs.Type, rs.Zone, rs.FQDN)
+}
+
+func parseRecordSetID(id string) (*api.RecordSet, error) {
+	values := strings.Split(id, "/")
+	if len(values) != 3 || values[0] == "" || values[1] == "" || values[2] == "" {
+		return nil, fmt.Errorf("invalid id provided, expected format: {type}/{zone}/{fqdn}")
+	}
+	return &api.RecordSet{Type: values[0], Zone: values[1], FQDN: values[2]}, nil
+}
+
+func expandRecordSet(d *schema.ResourceData) *api.RecordSet {
+	rs := &api.RecordSet{
+		Zone: d.Get("zone").(string),
+		Type: d.Get("type").(string),
+		TTL:  d.Get("ttl").(string),
+	}
+	if name := d.Get("name").(string); name == "" || name == rs.Zone {
+		rs.FQDN = rs.Zone
+	} else {
+		rs.FQDN = fmt.Sprintf("%s.%s", name, rs.Zone)
+	}
+	for _, value := range d.Get("values").(*schema.Set).List() {
+		rs.Values = append(rs.Values, value.(string))
+	}
+	sort.Strings(rs.Values)
+	return rs
+}
+
+func resourceDynRecordSetCreate(d *schema.ResourceData, meta interface{}) error {
+	provider := GetProvider(meta)
+
+	rs := expandRecordSet(d)
+	log.Printf("[DEBUG] Dyn record set create configuration: %#v", rs)
+
+	// replace the records of the node, and publish them with the other
+	// changes to the zone
+	err := provider.changeZone(context.Background(), rs.Zone, provider.publishNotes(d, "dyn_record_set"), func(client *api.ConvenientClient) error {
+		if err := client.ReplaceRecordSet(rs); err != nil {
+			return fmt.Errorf("Failed to create Dyn record set: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(recordSetID(rs))
+
+	return resourceDynRecordSetRead(d, meta)
+}
+
+func resourceDynRecordSetRead(d *schema.ResourceData, meta interface{}) error {
+	provider := GetProvider(meta)
+	client, err := provider.GetClient()
+	if err != nil {
+		return err
+	}
+	defer provider.PutClient(client)
+
+	return readDynRecordSet(d, client)
+}
+
+func readDynRecordSet(d *schema.ResourceData, client *api.ConvenientClient) error {
+	rs, err := parseRecordSetID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	err = client.GetRecordSet(rs)
+	if err != nil && !api.IsNotFound(err) {
+		return fmt.Errorf("Couldn't find Dyn record set: %s", err)
+	}
+	if err != nil || len(rs.Values) == 0 {
+		log.Printf("[WARN] Dyn record set %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("zone", rs.Zone)
+	d.Set("fqdn", rs.FQDN)
+	if rs.FQDN == rs.Zone {
+		d.Set("name", "")
+	} else {
+		d.Set("name", strings.TrimSuffix(rs.FQDN, "."+rs.Zone))
+	}
+	d.Set("type", rs.Type)
+	d.Set("ttl", rs.TTL)
+	d.Set("values", flattenRecordSetValues(rs.Type, rs.Values, d.Get("values").(*schema.Set)))
+
+	return nil
+}
+
+// flattenRecordSetValues returns the values read back, spelt as in the
+// configuration when they only differ by the trailing dot of a domain name.
+func flattenRecordSetValues(recordType string, values []string, configured *schema.Set) []string {
+	if !recordNameTypes[recordType] {
+		return values
+	}
+	spelling := make(map[string]string)
+	for _, v := range configured.List() {
+		spelling[strings.TrimSuffix(v.(string), ".")] = v.(string)
+	}
+	flattened := make([]string, 0, len(values))
+	for _, value := range values {
+		if v, ok := spelling[strings.TrimSuffix(value, ".")]; ok {
+			value = v
+		}
+		flattened = append(flattened, value)
+	}
+	return flattened
+}
+
+func resourceDynRecordSetUpdate(d *schema.ResourceData, meta interface{}) error {
+	provider := GetProvider(meta)
+
+	rs := expandRecordSet(d)
+	log.Printf("[DEBUG] Dyn record set update configuration: %#v", rs)
+
+	// replace the records of the node, and publish them with the other
+	// changes to the zone
+	err := provider.changeZone(context.Background(), rs.Zone, provider.publishNotes(d, "dyn_record_set"), func(client *api.ConvenientClient) error {
+		if err := client.ReplaceRecordSet(rs); err != nil {
+			return fmt.Errorf("Failed to update Dyn record set: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceDynRecordSetRead(d, meta)
+}
+
+func resourceDynRecordSetDelete(d *schema.ResourceData, meta interface{}) error {
+	provider := GetProvider(meta)
+
+	rs, err := parseRecordSetID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting Dyn record set: %s", d.Id())
+
+	// delete the records of the node, and publish it with the other changes
+	// to the zone
+	return provider.changeZone(context.Background(), rs.Zone, provider.publishNotes(d, "dyn_record_set"), func(client *api.ConvenientClient) error {
+		if err := client.DeleteRecordSet(rs); err != nil {
+			return fmt.Errorf("Failed to delete Dyn record set: %s", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+package dyn
+
+import (
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynRecordSet_lifecycle(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecordSet().Schema, map[string]interface{}{
+		"zone":   "example.com",
+		"name":   "www",
+		"type":   "A",
+		"ttl":    "300",
+		"values": []interface{}{"192.168.0.10", "192.168.0.11"},
+	})
+	if err := resourceDynRecordSetCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Id() != "A/example.com/www.example.com" {
+		t.Fatalf("unexpected ID: %s", d.Id())
+	}
+	testCheckDynRecordSetValues(t, d, "192.168.0.10", "192.168.0.11")
+	if ttl := d.Get("ttl").(string); ttl != "300" {
+		t.Fatalf("unexpected TTL: %s", ttl)
+	}
+
+	// Swapping an address is a single change
+	serial := server.Serial("example.com")
+	d.Set("values", []interface{}{"192.168.0.11", "192.168.0.12"})
+	if err := resourceDynRecordSetUpdate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testCheckDynRecordSetValues(t, d, "192.168.0.11", "192.168.0.12")
+	if n := server.CountRequests("PUT", "ARecord/"); n != 2 {
+		t.Fatalf("expected a single replace per apply, got %d", n)
+	}
+	if s := server.Serial("example.com"); s != serial+1 {
+		t.Fatalf("expected a single publish, serial went from %d to %d", serial, s)
+	}
+
+	// Import by ID
+	imported := schema.TestResourceDataRaw(t, resourceDynRecordSet().Schema, map[string]interface{}{})
+	imported.SetId(d.Id())
+	if err := resourceDynRecordSetRead(imported, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if name := imported.Get("name").(string); name != "www" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	testCheckDynRecordSetValues(t, imported, "192.168.0.11", "192.168.0.12")
+
+	if err := resourceDynRecordSetDelete(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynRecordSetRead(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Id() != "" {
+		t.Fatal("expected the deleted record set to be removed from state")
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 1 {
+		t.Fatalf("expected only the SOA record to remain, got %d records", n)
+	}
+}
+
+func TestDynRecordSet_trailingDot(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecordSet().Schema, map[string]interface{}{
+		"zone":   "example.com",
+		"type":   "MX",
+		"values": []interface{}{"10 mail1.example.com", "20 mail2.example.com."},
+	})
+	if err := resourceDynRecordSetCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	// Values keep the spelling of the configuration
+	testCheckDynRecordSetValues(t, d, "10 mail1.example.com", "20 mail2.example.com.")
+	// The apex is read back with an empty name, like dyn_record
+	if name := d.Get("name").(string); name != "" {
+		t.Fatalf("expected an empty name at the apex, got %s", name)
+	}
+
+	// The trailing dot is not ignored in the values of other types
+	d = schema.TestResourceDataRaw(t, resourceDynRecordSet().Schema, map[string]interface{}{
+		"zone":   "example.com",
+		"name":   "txt",
+		"type":   "TXT",
+		"values": []interface{}{"v=spf1 -all"},
+	})
+	if err := resourceDynRecordSetCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	d.Set("values", []interface{}{"v=spf1 -all."})
+	if err := resourceDynRecordSetRead(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testCheckDynRecordSetValues(t, d, "v=spf1 -all")
+}
+
+func testCheckDynRecordSetValues(t *testing.T, d *schema.ResourceData, expected ...string) {
+	t.Helper()
+	values := d.Get("values").(*schema.Set)
+	if values.Len() != len(expected) {
+		t.Fatalf("expected values %v, got %v", expected, values.List())
+	}
+	for _, v := range expected {
+		if !values.Contains(v) {
+			t.Fatalf("expected values %v, got %v", expected, values.List())
+		}
+	}
+}
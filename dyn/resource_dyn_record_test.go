@@ -1,13 +1,17 @@
 package dyn
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -235,6 +239,244 @@ func TestAccDynRecord_MX_record(t *testing.T) {
 	})
 }
 
+func TestDynRecord_lifecycle(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":  "example.com",
+		"name":  "terraform",
+		"type":  "A",
+		"ttl":   "3600",
+		"value": "192.168.0.10",
+	})
+
+	if err := resourceDynRecordCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Id() == "" {
+		t.Fatal("expected an ID to be set")
+	}
+	if fqdn := d.Get("fqdn").(string); fqdn != "terraform.example.com" {
+		t.Fatalf("expected fqdn terraform.example.com, got %s", fqdn)
+	}
+
+	d.Set("value", "192.168.0.11")
+	if err := resourceDynRecordUpdate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	records := server.PublishedRecords("example.com")
+	if last := records[len(records)-1]; last.RData.Address != "192.168.0.11" {
+		t.Fatalf("expected the update to be published, got %#v", last)
+	}
+
+	if err := resourceDynRecordDelete(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 1 {
+		t.Fatalf("expected only the SOA record to remain, got %d records", n)
+	}
+}
+
+func TestDynRecord_types(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	for recordType, value := range map[string]string{
+		"CAA":   `0 issue "letsencrypt.org"`,
+		"PTR":   "host.example.com.",
+		"SRV":   "10 20 5060 sip.example.com.",
+		"SSHFP": "1 1 123456789abcdef67890123456789abcdef67890",
+	} {
+		d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+			"zone":  "example.com",
+			"name":  strings.ToLower(recordType),
+			"type":  recordType,
+			"value": value,
+		})
+		if err := resourceDynRecordCreate(d, provider); err != nil {
+			t.Fatalf("%s: %s", recordType, err)
+		}
+		if err := resourceDynRecordRead(d, provider); err != nil {
+			t.Fatalf("%s: %s", recordType, err)
+		}
+		if got := d.Get("value").(string); got != value {
+			t.Fatalf("%s: expected value %q, got %q", recordType, value, got)
+		}
+	}
+}
+
+func TestDynRecord_dataBlocks(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone": "example.com",
+		"name": "_sip._tcp",
+		"type": "SRV",
+		"srv": []interface{}{
+			map[string]interface{}{"priority": 10, "weight": 20, "port": 5060, "target": "sip.example.com."},
+		},
+	})
+	if err := resourceDynRecordCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if value := d.Get("value").(string); value != "10 20 5060 sip.example.com." {
+		t.Fatalf("unexpected value: %s", value)
+	}
+	if port := d.Get("srv.0.port").(int); port != 5060 {
+		t.Fatalf("unexpected port: %d", port)
+	}
+
+	d.Set("srv", []interface{}{
+		map[string]interface{}{"priority": 10, "weight": 20, "port": 5061, "target": "sip.example.com."},
+	})
+	if err := resourceDynRecordUpdate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	records := server.PublishedRecords("example.com")
+	if last := records[len(records)-1]; last.RData.Port != "5061" {
+		t.Fatalf("expected the port to be updated, got %#v", last.RData)
+	}
+
+	d.Set("srv", []interface{}{
+		map[string]interface{}{"priority": 0, "weight": 20, "port": 5061, "target": "sip.example.com."},
+	})
+	if err := resourceDynRecordUpdate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if value := d.Get("value").(string); value != "0 20 5061 sip.example.com." {
+		t.Fatalf("expected a zero priority to be sent, got %s", value)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone": "example.com",
+		"type": "MX",
+		"mx": []interface{}{
+			map[string]interface{}{"preference": 0, "exchange": "."},
+		},
+	})
+	if err := resourceDynRecordCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if value := d.Get("value").(string); value != "0 ." {
+		t.Fatalf("expected a zero preference to be sent, got %s", value)
+	}
+	if preference := d.Get("mx.0.preference").(int); preference != 0 {
+		t.Fatalf("unexpected preference: %d", preference)
+	}
+
+	// The apex record is read back with an empty name, and updated in place
+	if name := d.Get("name").(string); name != "" {
+		t.Fatalf("expected an empty name for the apex record, got %q", name)
+	}
+	d.Set("mx", []interface{}{
+		map[string]interface{}{"preference": 5, "exchange": "."},
+	})
+	if err := resourceDynRecordUpdate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if value := d.Get("value").(string); value != "5 ." {
+		t.Fatalf("unexpected value: %s", value)
+	}
+}
+
+func TestDynRecord_validateDataBlocks(t *testing.T) {
+	cases := []struct {
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{
+				"zone": "example.com",
+				"type": "MX",
+				"mx":   []interface{}{map[string]interface{}{"preference": 70000, "exchange": "mail.example.com."}},
+			},
+			"mx.0.preference",
+		},
+		{
+			map[string]interface{}{
+				"zone":  "example.com",
+				"type":  "MX",
+				"value": "10 mail.example.com.",
+				"mx":    []interface{}{map[string]interface{}{"preference": 10, "exchange": "mail.example.com."}},
+			},
+			"only one of",
+		},
+		{
+			map[string]interface{}{
+				"zone": "example.com",
+				"type": "CAA",
+				"caa":  []interface{}{map[string]interface{}{"tag": "is sue", "value": "letsencrypt.org"}},
+			},
+			"caa.0.tag",
+		},
+	}
+
+	for _, tc := range cases {
+		diags := resourceDynRecord().Validate(terraform.NewResourceConfigRaw(tc.config))
+		if !diags.HasError() {
+			t.Errorf("expected %v to be invalid", tc.config)
+			continue
+		}
+		if message := fmt.Sprintf("%v", diags); !strings.Contains(message, tc.expected) {
+			t.Errorf("expected an error about %s, got %s", tc.expected, message)
+		}
+	}
+
+	_, err := resourceDynRecord().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"zone": "example.com",
+		"type": "A",
+		"mx":   []interface{}{map[string]interface{}{"preference": 10, "exchange": "mail.example.com."}},
+	}), nil)
+	if err == nil || !strings.Contains(err.Error(), "mx: block can only be set on MX records") {
+		t.Fatalf("expected an error about the mx block, got %v", err)
+	}
+}
+
+func TestDynRecord_deletedOutOfBand(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":  "example.com",
+		"name":  "terraform",
+		"type":  "A",
+		"ttl":   "3600",
+		"value": "192.168.0.10",
+	})
+	if err := resourceDynRecordCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	record := &api.Record{ID: d.Id(), Zone: "example.com", FQDN: "terraform.example.com", Type: "A"}
+	if err := client.DeleteRecord(record); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.PublishZone("example.com"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := resourceDynRecordRead(d, provider); err != nil {
+		t.Fatalf("expected a missing record not to fail, got %s", err)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the record to be removed from state, got ID %s", d.Id())
+	}
+}
+
 func testAccCheckDynRecordDestroy(s *terraform.State) error {
 	provider := GetProvider(testAccProvider.Meta())
 	client, err := provider.GetClient()
@@ -2,6 +2,7 @@ package dyn
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/Cdiscount/terraform-provider-dyn/api"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -13,6 +14,9 @@ func resourceDynTrafficDirector() *schema.Resource {
 		Read:   resourceDynTrafficDirectorRead,
 		Update: resourceDynTrafficDirectorUpdate,
 		Delete: resourceDynTrafficDirectorDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"label": {
@@ -44,17 +48,21 @@ func resourceDynTrafficDirector() *schema.Resource {
 					},
 				},
 			},
+			"publish_notes": publishNotesSchema(),
+			"pending_change": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the service has unpublished changes, see `dyn_traffic_director_publish`.",
+			},
 		},
 	}
 }
 
 func resourceDynTrafficDirectorCreate(d *schema.ResourceData, meta interface{}) error {
 	request := &api.DSFServiceRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Label: d.Get("label").(string),
-		TTL:   api.SInt(d.Get("ttl").(int)),
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_traffic_director"),
+		Label:        d.Get("label").(string),
+		TTL:          api.SInt(d.Get("ttl").(int)),
 	}
 	response := &api.DSFResponse{}
 
@@ -73,7 +81,7 @@ func resourceDynTrafficDirectorCreate(d *schema.ResourceData, meta interface{})
 	d.SetId(response.Data.ID)
 	load_dsf_service(d, &response.Data)
 
-	return updateDsfNodes(d, client)
+	return updateDsfNodes(d, meta, client)
 }
 
 func resourceDynTrafficDirectorRead(d *schema.ResourceData, meta interface{}) error {
@@ -91,6 +99,11 @@ func resourceDynTrafficDirectorRead(d *schema.ResourceData, meta interface{}) er
 	url := fmt.Sprintf("DSF/%s", id)
 	err = client.Do("GET", url, nil, response)
 	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
@@ -111,11 +124,9 @@ func resourceDynTrafficDirectorUpdate(d *schema.ResourceData, meta interface{})
 	if d.HasChanges("label", "ttl") {
 		id := d.Id()
 		request := &api.DSFServiceRequest{
-			PublishBlock: api.PublishBlock{
-				Publish: true,
-			},
-			Label: d.Get("label").(string),
-			TTL:   api.SInt(d.Get("ttl").(int)),
+			PublishBlock: provider.publishBlock(d, "dyn_traffic_director"),
+			Label:        d.Get("label").(string),
+			TTL:          api.SInt(d.Get("ttl").(int)),
 		}
 		response := &api.DSFResponse{}
 
@@ -126,7 +137,7 @@ func resourceDynTrafficDirectorUpdate(d *schema.ResourceData, meta interface{})
 		}
 		load_dsf_service(d, &response.Data)
 	}
-	return updateDsfNodes(d, client)
+	return updateDsfNodes(d, meta, client)
 }
 
 func resourceDynTrafficDirectorDelete(d *schema.ResourceData, meta interface{}) error {
@@ -139,9 +150,7 @@ func resourceDynTrafficDirectorDelete(d *schema.ResourceData, meta interface{})
 	}
 	defer provider.PutClient(client)
 
-	publish := api.PublishBlock{
-		Publish: true,
-	}
+	publish := provider.publishDeleteBlock(d, "dyn_traffic_director")
 	url := fmt.Sprintf("DSF/%s", id)
 	err = client.Do("DELETE", url, &publish, nil)
 	if err != nil {
@@ -151,13 +160,11 @@ func resourceDynTrafficDirectorDelete(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
-func updateDsfNodes(d *schema.ResourceData, client *api.ConvenientClient) error {
+func updateDsfNodes(d *schema.ResourceData, meta interface{}, client *api.ConvenientClient) error {
 	id := d.Id()
 	request := &api.DSFNodeRequest{
-		PublishBlock: api.PublishBlock{
-			Publish: true,
-		},
-		Node: nodes_from_schema(d),
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_traffic_director"),
+		Node:         nodes_from_schema(d),
 	}
 	response := &api.DSFNodeResponse{}
 	url_node := fmt.Sprintf("DSFNode/%s", id)
@@ -186,6 +193,7 @@ func nodes_from_schema(d *schema.ResourceData) []api.DSFNode {
 func load_dsf_service(d *schema.ResourceData, response *api.DSFService) {
 	d.Set("label", response.Label)
 	d.Set("ttl", response.TTL)
+	d.Set("pending_change", response.PendingChange == "Y")
 }
 
 func load_nodes(raw_nodes []api.DSFNode, d *schema.ResourceData) {
@@ -0,0 +1,119 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceDynTrafficDirectorPublish() *schema.Resource {
+	return &schema.Resource{
+		Description: "Publishes the staged changes of a Traffic Director service at once. " +
+			"It is meant to depend on the Traffic Director objects of the service, " +
+			"created with `stage_traffic_director_changes` set in the provider. " +
+			"Every object of the service must feed its `triggers`, so that their updates publish the service again.",
+
+		CreateContext: resourceDynTrafficDirectorPublishCreate,
+		ReadContext:   resourceDynTrafficDirectorPublishRead,
+		UpdateContext: resourceDynTrafficDirectorPublishUpdate,
+		DeleteContext: resourceDynTrafficDirectorPublishDelete,
+		CustomizeDiff: resourceDynTrafficDirectorPublishCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"traffic_director_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Traffic Director service to publish.",
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values which publish the service again when they change. " +
+					"Every staged object of the service must feed them, e.g. with its attributes: " +
+					"the changes of the objects which do not are left unpublished.",
+			},
+			"publish_notes": publishNotesSchema(),
+			"pending_change": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the service has unpublished changes, e.g. left by a failed apply.",
+			},
+		},
+	}
+}
+
+func resourceDynTrafficDirectorPublishCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId(d.Get("traffic_director_id").(string))
+	return resourceDynTrafficDirectorPublishUpdate(ctx, d, meta)
+}
+
+func resourceDynTrafficDirectorPublishRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	response := &api.DSFResponse{}
+	url := fmt.Sprintf("DSF/%s", d.Id())
+	err = client.DoContext(ctx, "GET", url, nil, response)
+	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	d.Set("traffic_director_id", response.Data.ID)
+	d.Set("pending_change", response.Data.PendingChange == "Y")
+
+	return nil
+}
+
+func resourceDynTrafficDirectorPublishUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	// publish the service, whatever stage_traffic_director_changes
+	request := api.PublishBlock{
+		Publish: true,
+		Notes:   provider.publishNotes(d, "dyn_traffic_director_publish"),
+	}
+	response := &api.DSFResponse{}
+	url := fmt.Sprintf("DSF/%s", d.Id())
+	err = client.DoContext(ctx, "PUT", url, &request, response)
+	if err != nil {
+		return diag.Errorf("Failed to publish Dyn Traffic Director %s: %s", d.Id(), err)
+	}
+
+	d.Set("pending_change", response.Data.PendingChange == "Y")
+
+	return nil
+}
+
+// Publishing a service has nothing to undo
+func resourceDynTrafficDirectorPublishDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+// resourceDynTrafficDirectorPublishCustomizeDiff plans a publish when the
+// service has unpublished changes.
+func resourceDynTrafficDirectorPublishCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && d.Get("pending_change").(bool) {
+		return d.SetNew("pending_change", false)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package dyn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDynTrafficDirectorPublish_staged(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	provider.stageTrafficDirectorChanges = true
+	ctx := context.Background()
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+		"ttl":   300,
+	}, provider)
+	pool := testCreateTrafficDirectorChain(t, td, provider).pool
+	if err := resourceDynTrafficDirectorRead(td, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !td.Get("pending_change").(bool) || len(server.ServicePublishNotes(td.Id())) != 0 {
+		t.Fatal("expected the changes to the service to be staged")
+	}
+
+	publish := schema.TestResourceDataRaw(t, resourceDynTrafficDirectorPublish().Schema, map[string]interface{}{
+		"traffic_director_id": td.Id(),
+	})
+	if diags := resourceDynTrafficDirectorPublishCreate(ctx, publish, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if publish.Get("pending_change").(bool) || server.ServicePendingChange(td.Id()) {
+		t.Fatal("expected the service to be published")
+	}
+	if n := len(server.ServicePublishNotes(td.Id())); n != 1 {
+		t.Fatalf("expected a single publish, got %d", n)
+	}
+
+	// Changes left unpublished are published by the next apply
+	pool.Set("label", "renamed-response-pool")
+	if err := resourceDynDSFResponsePoolUpdate(pool, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diags := resourceDynTrafficDirectorPublishRead(ctx, publish, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if !publish.Get("pending_change").(bool) {
+		t.Fatal("expected the staged change to be reported")
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"traffic_director_id": td.Id()})
+	diff, err := resourceDynTrafficDirectorPublish().Diff(ctx, publish.State(), config, provider)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff == nil || diff.Attributes["pending_change"] == nil {
+		t.Fatalf("expected a publish to be planned, got %#v", diff)
+	}
+	if diags := resourceDynTrafficDirectorPublishUpdate(ctx, publish, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if server.ServicePendingChange(td.Id()) {
+		t.Fatal("expected the service to be published")
+	}
+}
+
+func TestDynTrafficDirectorPublish_stagedDelete(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	provider.stageTrafficDirectorChanges = true
+	ctx := context.Background()
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+	ruleset := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "all",
+		"traffic_director_id": td.Id(),
+	})
+	if err := resourceDynDSFRulesetCreate(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	publish := schema.TestResourceDataRaw(t, resourceDynTrafficDirectorPublish().Schema, map[string]interface{}{
+		"traffic_director_id": td.Id(),
+	})
+	if diags := resourceDynTrafficDirectorPublishCreate(ctx, publish, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+
+	// The deleted ruleset no longer feeds the triggers of the publish, so its
+	// delete is published at once
+	if err := resourceDynDSFRulesetDelete(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if server.ServicePendingChange(td.Id()) {
+		t.Fatal("expected the delete to be published")
+	}
+	if n := len(server.ServicePublishNotes(td.Id())); n != 2 {
+		t.Fatalf("expected two publishes, got %d", n)
+	}
+}
@@ -0,0 +1,142 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDynTrafficDirectorRulesetOrder() *schema.Resource {
+	return &schema.Resource{
+		Description: "Sets the evaluation order of the rulesets of a Traffic Director service at once. " +
+			"Unlike the `ordering` of `dyn_dsf_ruleset`, which is an absolute position shifted by the creation and deletion of other rulesets, " +
+			"the order is given by the list of the rulesets, so that deleting a ruleset only removes it from the list. " +
+			"Leave out the `ordering` of the listed rulesets. " +
+			"There can be only one such resource per service, as its ID is the ID of the service.",
+
+		CreateContext: resourceDynTrafficDirectorRulesetOrderCreate,
+		ReadContext:   resourceDynTrafficDirectorRulesetOrderRead,
+		UpdateContext: resourceDynTrafficDirectorRulesetOrderUpdate,
+		DeleteContext: resourceDynTrafficDirectorRulesetOrderDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"traffic_director_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Traffic Director service.",
+			},
+			"ruleset_ids": {
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringIsNotEmpty},
+				Description: "IDs of the rulesets, in the order they are evaluated. Rulesets of the service which are not listed are evaluated after them.",
+			},
+			"publish_notes": publishNotesSchema(),
+		},
+	}
+}
+
+func resourceDynTrafficDirectorRulesetOrderCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId(d.Get("traffic_director_id").(string))
+	return resourceDynTrafficDirectorRulesetOrderUpdate(ctx, d, meta)
+}
+
+func resourceDynTrafficDirectorRulesetOrderRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	response := &api.AllDSFRulesetsResponse{}
+	url := fmt.Sprintf("DSFRuleset/%s", d.Id())
+	err = client.DoContext(ctx, "GET", url, nil, response)
+	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	d.Set("traffic_director_id", d.Id())
+	d.Set("ruleset_ids", flattenRulesetOrder(response.Data, d.Get("ruleset_ids").([]interface{})))
+
+	return nil
+}
+
+// flattenRulesetOrder returns the IDs of the rulesets in their evaluation
+// order, up to the last listed one, or of every ruleset when none are listed,
+// e.g. on import. The rulesets which are not listed are only left out after
+// the listed ones, where they are expected: one moved before them shows a
+// diff.
+func flattenRulesetOrder(rulesets []api.DSFRuleset, listed []interface{}) []interface{} {
+	sort.SliceStable(rulesets, func(i, j int) bool {
+		a, _ := strconv.Atoi(rulesets[i].Ordering)
+		b, _ := strconv.Atoi(rulesets[j].Ordering)
+		return a < b
+	})
+	keep := make(map[string]bool, len(listed))
+	for _, id := range listed {
+		keep[id.(string)] = true
+	}
+	last := len(rulesets) - 1
+	if len(keep) > 0 {
+		for last >= 0 && !keep[rulesets[last].ID] {
+			last--
+		}
+	}
+	ids := make([]interface{}, 0, last+1)
+	for _, rs := range rulesets[:last+1] {
+		ids = append(ids, rs.ID)
+	}
+	return ids
+}
+
+func resourceDynTrafficDirectorRulesetOrderUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// move each ruleset in turn to its position, the rulesets before it
+	// being in place already, and publish the service with the last move
+	ids := d.Get("ruleset_ids").([]interface{})
+	for i, id := range ids {
+		request := api.DSFRulesetOrderingRequest{Ordering: strconv.Itoa(i)}
+		if i == len(ids)-1 {
+			request.PublishBlock = provider.publishBlock(d, "dyn_traffic_director_ruleset_order")
+		}
+		url := fmt.Sprintf("DSFRuleset/%s/%s", d.Id(), id.(string))
+		err = client.DoContext(ctx, "PUT", url, &request, nil)
+		if err != nil {
+			// Do not leave the moves staged so far to the next user of the
+			// session
+			provider.logoutClient(client)
+			return diag.Errorf("Failed to move Dyn Traffic Director ruleset %s to %d: %s", id, i, err)
+		}
+	}
+	provider.PutClient(client)
+
+	return resourceDynTrafficDirectorRulesetOrderRead(ctx, d, meta)
+}
+
+// The rulesets are left in place
+func resourceDynTrafficDirectorRulesetOrderDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
@@ -0,0 +1,168 @@
+package dyn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDynTrafficDirectorRulesetOrder(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	ctx := context.Background()
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+	rulesets := map[string]*schema.ResourceData{}
+	for _, label := range []string{"a", "b", "c"} {
+		d := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+			"label":               label,
+			"traffic_director_id": td.Id(),
+		})
+		if err := resourceDynDSFRulesetCreate(d, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		rulesets[label] = d
+	}
+	checkOrdering := func(expected map[string]int) {
+		t.Helper()
+		for label, ordering := range expected {
+			d := rulesets[label]
+			if err := resourceDynDSFRulesetRead(d, provider); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got := d.Get("ordering").(int); got != ordering {
+				t.Fatalf("expected ruleset %s at %d, got %d", label, ordering, got)
+			}
+		}
+	}
+
+	// The state of c before it is moved first
+	stale := resourceDynDSFRuleset().Data(rulesets["c"].State())
+
+	r := resourceDynTrafficDirectorRulesetOrder()
+	config := map[string]interface{}{
+		"traffic_director_id": td.Id(),
+		"ruleset_ids":         []interface{}{rulesets["c"].Id(), rulesets["a"].Id(), rulesets["b"].Id()},
+	}
+	publishes := len(server.ServicePublishNotes(td.Id()))
+	order := schema.TestResourceDataRaw(t, r.Schema, config)
+	if diags := resourceDynTrafficDirectorRulesetOrderCreate(ctx, order, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	checkOrdering(map[string]int{"c": 0, "a": 1, "b": 2})
+	if n := len(server.ServicePublishNotes(td.Id())); n != publishes+1 {
+		t.Fatalf("expected the rulesets to be ordered with a single publish, got %d publishes", n-publishes)
+	}
+
+	// Deleting a ruleset shifts the absolute ordering of the next ones, but
+	// not their order
+	if err := resourceDynDSFRulesetDelete(rulesets["a"], provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	delete(rulesets, "a")
+	config["ruleset_ids"] = []interface{}{rulesets["c"].Id(), rulesets["b"].Id()}
+	order = testPlanResource(t, r, order, config, provider)
+	if diags := resourceDynTrafficDirectorRulesetOrderUpdate(ctx, order, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if diags := resourceDynTrafficDirectorRulesetOrderRead(ctx, order, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if ids := order.Get("ruleset_ids").([]interface{}); !reflect.DeepEqual(ids, config["ruleset_ids"]) {
+		t.Fatalf("unexpected order: %v", ids)
+	}
+	diff, err := r.Diff(ctx, order.State(), terraform.NewResourceConfigRaw(config), provider)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Fatalf("expected no diff, got %#v", diff.Attributes)
+	}
+
+	// A ruleset created before the listed ones outside of the resource shows
+	// a diff, and is moved after them
+	outside := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "outside",
+		"traffic_director_id": td.Id(),
+		"ordering":            0,
+	})
+	if err := resourceDynDSFRulesetCreate(outside, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	rulesets["outside"] = outside
+	if diags := resourceDynTrafficDirectorRulesetOrderRead(ctx, order, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	expected := []interface{}{outside.Id(), rulesets["c"].Id(), rulesets["b"].Id()}
+	if ids := order.Get("ruleset_ids").([]interface{}); !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected the drift %v, got %v", expected, ids)
+	}
+	order = testPlanResource(t, r, order, config, provider)
+	if diags := resourceDynTrafficDirectorRulesetOrderUpdate(ctx, order, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if ids := order.Get("ruleset_ids").([]interface{}); !reflect.DeepEqual(ids, config["ruleset_ids"]) {
+		t.Fatalf("unexpected order: %v", ids)
+	}
+	checkOrdering(map[string]int{"c": 0, "b": 1, "outside": 2})
+
+	// A failed move logs out of the session holding the moves staged before
+	config["ruleset_ids"] = []interface{}{rulesets["b"].Id(), "missing", rulesets["c"].Id()}
+	failed := testPlanResource(t, r, order, config, provider)
+	if diags := resourceDynTrafficDirectorRulesetOrderUpdate(ctx, failed, provider); !diags.HasError() {
+		t.Fatal("expected the move of a missing ruleset to fail")
+	}
+	provider.mutex.Lock()
+	idle, sessions := len(provider.clients), provider.sessions
+	provider.mutex.Unlock()
+	if idle != 0 || sessions != 0 {
+		t.Fatalf("expected the session to be logged out, got %d idle of %d sessions", idle, sessions)
+	}
+
+	// Updating a ruleset whose ordering in state is stale does not move it
+	stale = testPlanResource(t, resourceDynDSFRuleset(), stale, map[string]interface{}{
+		"label":               "renamed",
+		"traffic_director_id": td.Id(),
+	}, provider)
+	if err := resourceDynDSFRulesetUpdate(stale, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	checkOrdering(map[string]int{"b": 0, "c": 1, "outside": 2})
+}
+
+func TestDynTrafficDirectorRulesetOrder_import(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+	}, provider)
+	var ids []interface{}
+	for _, label := range []string{"a", "b"} {
+		d := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+			"label":               label,
+			"traffic_director_id": td.Id(),
+			"ordering":            0,
+		})
+		if err := resourceDynDSFRulesetCreate(d, provider); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		ids = append([]interface{}{d.Id()}, ids...)
+	}
+
+	imported := testImportResource(t, resourceDynTrafficDirectorRulesetOrder(), td.Id(), provider)
+	if got := imported.Get("ruleset_ids").([]interface{}); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("expected the rulesets %v, got %v", ids, got)
+	}
+	if id := imported.Get("traffic_director_id").(string); id != td.Id() {
+		t.Fatalf("unexpected traffic_director_id: %s", id)
+	}
+}
@@ -0,0 +1,476 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDynTrafficDirectorService() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDynTrafficDirectorServiceCreate,
+		ReadContext:   resourceDynTrafficDirectorServiceRead,
+		UpdateContext: resourceDynTrafficDirectorServiceUpdate,
+		DeleteContext: resourceDynTrafficDirectorServiceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		CustomizeDiff: resourceDynTrafficDirectorServiceCustomizeDiff,
+
+		Description: "Dynect Traffic Director service defined as a whole, from its rulesets down to its records, and created and updated with a single publish",
+		Schema: map[string]*schema.Schema{
+			"label": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Traffic Director service",
+			},
+			"ttl": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The default TTL to be used across the service",
+			},
+			"node": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"zone": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the zone",
+						},
+						"fqdn": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Fully qualified domain name of a node in the zone",
+						},
+					},
+				},
+			},
+			"ruleset": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Rulesets of the service, in their evaluation order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"label": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A label for the Ruleset",
+						},
+						"criteria_type": dsfCriteriaTypeSchema(),
+						"geoip":         dsfGeoIPSchema(nil),
+						"response_pool": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Response pools of the Ruleset, in their failover order",
+							Elem:        resourceDynTrafficDirectorServiceResponsePool(),
+						},
+					},
+				},
+			},
+			"publish_notes": publishNotesSchema(),
+			"pending_change": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the service has unpublished changes, see `dyn_traffic_director_publish`.",
+			},
+		},
+	}
+}
+
+func resourceDynTrafficDirectorServiceResponsePool() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"label": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Response pool name",
+			},
+			"automation": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "auto",
+				ValidateFunc: validation.StringInSlice([]string{"auto", "auto_down", "manual"}, false),
+				Description: `Defines how eligible can be changed in response to monitoring. Defaults to auto.
+  * auto — Sets the serve_mode field to ‘Monitor & Obey’.
+  * auto_down — Sets the serve_mode field to ‘Monitor & Remove’.
+  * manual — Couples with eligible value to determine other serve_mode field values`,
+			},
+			"rs_chain": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Record Set Failover Chains of the response pool",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"label": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A label for the Record Set Failover Chain",
+						},
+						"core": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether the Record Set Failover Chain is the core chain of the response pool",
+						},
+						"record_set": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Record Sets of the chain, in their failover order",
+							Elem:        resourceDynTrafficDirectorServiceRecordSet(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceDynTrafficDirectorServiceRecordSet() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"label": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Record set name",
+			},
+			"rdata_class": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"A", "AAAA"}, false),
+				Description:  "The type of rdata represented by this Record Set",
+			},
+			"ttl": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Default TTL used for Records within this Record Set, the TTL of the service when not set",
+			},
+			"automation": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "auto",
+				ValidateFunc: validation.StringInSlice([]string{"auto", "auto_down", "manual"}, false),
+				Description:  "Defines how eligible can be changed in response to monitoring, as for the response pools. Defaults to auto.",
+			},
+			"serve_count": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "How many Records to serve out of this Record Set",
+			},
+			"fail_count": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "The number of Records that must not be okay before the Record Set becomes ineligible",
+			},
+			"trouble_count": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "The number of Records that must not be okay before the Record Set becomes in trouble",
+			},
+			"eligible": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Indicates whether or not the Record Set can be served",
+			},
+			"monitor_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The ID of the monitor of the Record Set",
+			},
+			"record": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Records of the Record Set",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"label": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A label for the Record",
+						},
+						"master_line": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The value to put in the record, i.e. 1.2.3.4 for a DNS A record",
+						},
+						"weight": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntBetween(1, 15),
+							Description:  "Weight for the Record, from 1 to 15 for A or AAAA records. Defaults to 1.",
+						},
+						"automation": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "auto",
+							ValidateFunc: validation.StringInSlice([]string{"auto", "auto_down", "manual"}, false),
+							Description:  "Defines how eligible can be changed in response to monitoring, as for the response pools. Defaults to auto.",
+						},
+						"eligible": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Indicates whether or not the Record can be served",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceDynTrafficDirectorServiceCustomizeDiff checks that the geoip block
+// is set on geoip rulesets only.
+func resourceDynTrafficDirectorServiceCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for i := range d.Get("ruleset").([]interface{}) {
+		path := fmt.Sprintf("ruleset.%d.", i)
+		criteriaType := d.Get(path + "criteria_type").(string)
+		geoip := d.Get(path + "geoip").([]interface{})
+		if err := checkDSFCriteria(path, criteriaType, geoip, d.NewValueKnown(path+"geoip")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resourceDynTrafficDirectorServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	request := computeDSFServiceRequest(d, meta)
+	response := &api.DSFResponse{}
+	err = client.DoContext(ctx, "POST", "DSF", request, response)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(response.Data.ID)
+	load_dsf_service_tree(d, &response.Data)
+
+	return nil
+}
+
+func resourceDynTrafficDirectorServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	response := &api.DSFResponse{}
+	url := fmt.Sprintf("DSF/%s", d.Id())
+	err = client.DoContext(ctx, "GET", url, nil, response)
+	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn Traffic Director %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	load_dsf_service_tree(d, &response.Data)
+
+	return nil
+}
+
+func resourceDynTrafficDirectorServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	// The whole tree is sent, Dyn replacing the rulesets of the service
+	request := computeDSFServiceRequest(d, meta)
+	response := &api.DSFResponse{}
+	url := fmt.Sprintf("DSF/%s", d.Id())
+	err = client.DoContext(ctx, "PUT", url, request, response)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	load_dsf_service_tree(d, &response.Data)
+
+	return nil
+}
+
+func resourceDynTrafficDirectorServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	publish := provider.publishDeleteBlock(d, "dyn_traffic_director_service")
+	url := fmt.Sprintf("DSF/%s", d.Id())
+	err = client.DoContext(ctx, "DELETE", url, &publish, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func computeDSFServiceRequest(d *schema.ResourceData, meta interface{}) *api.DSFServiceRequest {
+	nodes := nodes_from_schema(d)
+
+	raw_rulesets := d.Get("ruleset").([]interface{})
+	rulesets := make([]api.DSFRuleset, len(raw_rulesets))
+	for i, raw_ruleset := range raw_rulesets {
+		ruleset := raw_ruleset.(map[string]interface{})
+		rulesets[i] = api.DSFRuleset{
+			Label:         ruleset["label"].(string),
+			CriteriaType:  ruleset["criteria_type"].(string),
+			Criteria:      *expandDSFCriteria(ruleset["geoip"].([]interface{})),
+			ResponsePools: expandDSFResponsePools(ruleset["response_pool"].([]interface{})),
+		}
+	}
+
+	return &api.DSFServiceRequest{
+		PublishBlock: GetProvider(meta).publishBlock(d, "dyn_traffic_director_service"),
+		Label:        d.Get("label").(string),
+		TTL:          api.SInt(d.Get("ttl").(int)),
+		Nodes:        &nodes,
+		Rulesets:     &rulesets,
+	}
+}
+
+func expandDSFResponsePools(raw_pools []interface{}) []api.DSFResponsePool {
+	pools := make([]api.DSFResponsePool, len(raw_pools))
+	for i, raw_pool := range raw_pools {
+		pool := raw_pool.(map[string]interface{})
+		raw_chains := pool["rs_chain"].([]interface{})
+		chains := make([]api.DSFRecordSetChain, len(raw_chains))
+		for j, raw_chain := range raw_chains {
+			chain := raw_chain.(map[string]interface{})
+			chains[j] = api.DSFRecordSetChain{
+				Label:         chain["label"].(string),
+				Core:          strconv.FormatBool(chain["core"].(bool)),
+				DSFRecordSets: expandDSFRecordSets(chain["record_set"].([]interface{})),
+			}
+		}
+		pools[i] = api.DSFResponsePool{
+			Label:      pool["label"].(string),
+			Automation: pool["automation"].(string),
+			RsChains:   chains,
+		}
+	}
+	return pools
+}
+
+func expandDSFRecordSets(raw_sets []interface{}) []api.DSFRecordSet {
+	sets := make([]api.DSFRecordSet, len(raw_sets))
+	for i, raw_set := range raw_sets {
+		set := raw_set.(map[string]interface{})
+		raw_records := set["record"].([]interface{})
+		records := make([]api.DSFRecord, len(raw_records))
+		for j, raw_record := range raw_records {
+			record := raw_record.(map[string]interface{})
+			records[j] = api.DSFRecord{
+				Label:      record["label"].(string),
+				MasterLine: record["master_line"].(string),
+				Weight:     record["weight"].(int),
+				Automation: record["automation"].(string),
+				Eligible:   api.SBool(record["eligible"].(bool)),
+			}
+		}
+		sets[i] = api.DSFRecordSet{
+			Label:        set["label"].(string),
+			RDataClass:   set["rdata_class"].(string),
+			TTL:          api.SInt(set["ttl"].(int)),
+			Automation:   set["automation"].(string),
+			ServeCount:   api.SInt(set["serve_count"].(int)),
+			FailCount:    api.SInt(set["fail_count"].(int)),
+			TroubleCount: api.SInt(set["trouble_count"].(int)),
+			Eligible:     api.SBool(set["eligible"].(bool)),
+			MonitorID:    set["monitor_id"].(string),
+			Records:      records,
+		}
+	}
+	return sets
+}
+
+func load_dsf_service_tree(d *schema.ResourceData, response *api.DSFService) {
+	load_dsf_service(d, response)
+	load_nodes(response.Nodes, d)
+
+	rulesets := make([]interface{}, len(response.Rulesets))
+	for i, ruleset := range response.Rulesets {
+		rulesets[i] = map[string]interface{}{
+			"label":         ruleset.Label,
+			"criteria_type": ruleset.CriteriaType,
+			"geoip":         flattenDSFCriteria(&ruleset.Criteria),
+			"response_pool": flattenDSFResponsePools(ruleset.ResponsePools),
+		}
+	}
+	d.Set("ruleset", rulesets)
+}
+
+func flattenDSFResponsePools(pools []api.DSFResponsePool) []interface{} {
+	raw_pools := make([]interface{}, len(pools))
+	for i, pool := range pools {
+		chains := make([]interface{}, len(pool.RsChains))
+		for j, chain := range pool.RsChains {
+			chains[j] = map[string]interface{}{
+				"label":      chain.Label,
+				"core":       chain.Core == "true",
+				"record_set": flattenDSFRecordSets(chain.DSFRecordSets),
+			}
+		}
+		raw_pools[i] = map[string]interface{}{
+			"label":      pool.Label,
+			"automation": pool.Automation,
+			"rs_chain":   chains,
+		}
+	}
+	return raw_pools
+}
+
+func flattenDSFRecordSets(sets []api.DSFRecordSet) []interface{} {
+	raw_sets := make([]interface{}, len(sets))
+	for i, set := range sets {
+		records := make([]interface{}, len(set.Records))
+		for j, record := range set.Records {
+			records[j] = map[string]interface{}{
+				"label":       record.Label,
+				"master_line": record.MasterLine,
+				"weight":      record.Weight,
+				"automation":  record.Automation,
+				"eligible":    bool(record.Eligible),
+			}
+		}
+		raw_sets[i] = map[string]interface{}{
+			"label":         set.Label,
+			"rdata_class":   set.RDataClass,
+			"ttl":           int(set.TTL),
+			"automation":    set.Automation,
+			"serve_count":   int(set.ServeCount),
+			"fail_count":    int(set.FailCount),
+			"trouble_count": int(set.TroubleCount),
+			"eligible":      bool(set.Eligible),
+			"monitor_id":    set.MonitorID,
+			"record":        records,
+		}
+	}
+	return raw_sets
+}
@@ -0,0 +1,197 @@
+package dyn
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func testDSFServiceRecordSet(label, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"label":       label,
+		"rdata_class": "A",
+		"ttl":         60,
+		"record": []interface{}{
+			map[string]interface{}{"label": label + "-1", "master_line": value},
+			map[string]interface{}{"label": label + "-2", "master_line": value, "eligible": false, "automation": "manual"},
+		},
+	}
+}
+
+func TestDynTrafficDirectorService_lifecycle(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+	ctx := context.Background()
+
+	d := schema.TestResourceDataRaw(t, resourceDynTrafficDirectorService().Schema, map[string]interface{}{
+		"label": "my-traffic-director",
+		"ttl":   300,
+		"node": []interface{}{
+			map[string]interface{}{"zone": "example.com", "fqdn": "www.example.com"},
+		},
+		"ruleset": []interface{}{
+			map[string]interface{}{
+				"label":         "europe",
+				"criteria_type": "geoip",
+				"geoip": []interface{}{map[string]interface{}{
+					"region":  []interface{}{"15", "16"},
+					"country": []interface{}{"GB"},
+				}},
+				"response_pool": []interface{}{map[string]interface{}{
+					"label": "europe",
+					"rs_chain": []interface{}{map[string]interface{}{
+						"label":      "europe",
+						"core":       true,
+						"record_set": []interface{}{testDSFServiceRecordSet("paris", "192.168.0.10")},
+					}},
+				}},
+			},
+			map[string]interface{}{
+				"label": "all",
+				"response_pool": []interface{}{map[string]interface{}{
+					"label":      "all",
+					"automation": "auto_down",
+					"rs_chain": []interface{}{map[string]interface{}{
+						"label": "all",
+						"record_set": []interface{}{
+							testDSFServiceRecordSet("paris", "192.168.0.10"),
+							testDSFServiceRecordSet("dallas", "192.168.1.10"),
+						},
+					}},
+				}},
+			},
+		},
+	})
+	expected := computeDSFServiceRequest(d, provider)
+
+	if diags := resourceDynTrafficDirectorServiceCreate(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if notes := server.ServicePublishNotes(d.Id()); len(notes) != 1 {
+		t.Fatalf("expected the service to be published once, got %d publishes", len(notes))
+	}
+
+	// The whole tree is read back as configured
+	read := schema.TestResourceDataRaw(t, resourceDynTrafficDirectorService().Schema, map[string]interface{}{})
+	read.SetId(d.Id())
+	if diags := resourceDynTrafficDirectorServiceRead(ctx, read, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	actual := computeDSFServiceRequest(read, provider)
+	if !reflect.DeepEqual(actual.Rulesets, expected.Rulesets) {
+		t.Fatalf("unexpected rulesets:\n%#v\nexpected:\n%#v", *actual.Rulesets, *expected.Rulesets)
+	}
+	if !reflect.DeepEqual(actual.Nodes, expected.Nodes) || actual.Label != expected.Label || actual.TTL != expected.TTL {
+		t.Fatalf("unexpected service: %#v", actual)
+	}
+
+	// Updates replace the tree with a single publish
+	updated := schema.TestResourceDataRaw(t, resourceDynTrafficDirectorService().Schema, map[string]interface{}{
+		"label": "my-traffic-director",
+		"ttl":   300,
+		"ruleset": []interface{}{
+			map[string]interface{}{
+				"label": "all",
+				"response_pool": []interface{}{map[string]interface{}{
+					"label": "all",
+					"rs_chain": []interface{}{map[string]interface{}{
+						"label":      "all",
+						"record_set": []interface{}{testDSFServiceRecordSet("dallas", "192.168.1.20")},
+					}},
+				}},
+			},
+		},
+	})
+	updated.SetId(d.Id())
+	d = updated
+	expected = computeDSFServiceRequest(d, provider)
+	if diags := resourceDynTrafficDirectorServiceUpdate(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if notes := server.ServicePublishNotes(d.Id()); len(notes) != 2 {
+		t.Fatalf("expected the service to be published twice, got %d publishes", len(notes))
+	}
+	if diags := resourceDynTrafficDirectorServiceRead(ctx, read, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if actual := computeDSFServiceRequest(read, provider); !reflect.DeepEqual(actual.Rulesets, expected.Rulesets) {
+		t.Fatalf("unexpected rulesets:\n%#v\nexpected:\n%#v", *actual.Rulesets, *expected.Rulesets)
+	}
+
+	if diags := resourceDynTrafficDirectorServiceDelete(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if diags := resourceDynTrafficDirectorServiceRead(ctx, read, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if read.Id() != "" {
+		t.Fatal("expected the service to be removed from state")
+	}
+}
+
+func TestDynTrafficDirectorService_validateGeoIP(t *testing.T) {
+	cases := []struct {
+		ruleset  map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{"label": "europe", "criteria_type": "geoip"},
+			"ruleset.1.geoip: block is required",
+		},
+		{
+			map[string]interface{}{
+				"label": "europe",
+				"geoip": []interface{}{map[string]interface{}{"country": []interface{}{"GB"}}},
+			},
+			"ruleset.1.geoip: block can only be set when criteria_type is geoip",
+		},
+		{
+			map[string]interface{}{
+				"label": "europe", "criteria_type": "geoip",
+				"geoip": []interface{}{map[string]interface{}{}},
+			},
+			"ruleset.1.geoip: one of region, country or province must be specified",
+		},
+	}
+	for _, tc := range cases {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"label":   "my-traffic-director",
+			"ruleset": []interface{}{map[string]interface{}{"label": "all"}, tc.ruleset},
+		})
+		_, err := resourceDynTrafficDirectorService().Diff(context.Background(), nil, config, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("expected an error about %s, got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestDynTrafficDirectorService_validateWeight(t *testing.T) {
+	for weight, valid := range map[int]bool{1: true, 15: true, 16: false} {
+		recordSet := testDSFServiceRecordSet("paris", "192.168.0.10")
+		recordSet["record"].([]interface{})[0].(map[string]interface{})["weight"] = weight
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"label": "my-traffic-director",
+			"ruleset": []interface{}{map[string]interface{}{
+				"label": "all",
+				"response_pool": []interface{}{map[string]interface{}{
+					"label": "all",
+					"rs_chain": []interface{}{map[string]interface{}{
+						"label":      "all",
+						"record_set": []interface{}{recordSet},
+					}},
+				}},
+			}},
+		})
+		diags := resourceDynTrafficDirectorService().Validate(config)
+		if diags.HasError() == valid {
+			t.Errorf("weight %d: expected valid %t, got %#v", weight, valid, diags)
+		}
+	}
+}
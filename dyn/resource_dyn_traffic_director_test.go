@@ -0,0 +1,269 @@
+package dyn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestDynTrafficDirector_lifecycle(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+	ctx := context.Background()
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+		"ttl":   300,
+		"node": []interface{}{
+			map[string]interface{}{"zone": "example.com", "fqdn": "www.example.com"},
+		},
+	}, provider)
+	chain := testCreateTrafficDirectorChain(t, td, provider)
+	monitor, pool, rsfc, recordSet, record := chain.monitor, chain.pool, chain.rsfc, chain.recordSet, chain.record
+
+	ruleset := schema.TestResourceDataRaw(t, resourceDynDSFRuleset().Schema, map[string]interface{}{
+		"label":               "my-ruleset",
+		"traffic_director_id": td.Id(),
+		"response_pool_ids":   []interface{}{pool.Id()},
+	})
+	if err := resourceDynDSFRulesetCreate(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := resourceDynTrafficDirectorRead(td, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if label := td.Get("label").(string); label != "my-traffic-director" {
+		t.Fatalf("unexpected label: %s", label)
+	}
+	if fqdn := td.Get("node.0.fqdn").(string); fqdn != "www.example.com" {
+		t.Fatalf("unexpected node: %s", fqdn)
+	}
+	if diags := resourceDynDSFRecordSetRead(ctx, recordSet, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if id := recordSet.Get("monitor_id").(string); id != monitor.Id() {
+		t.Fatalf("expected monitor %s, got %s", monitor.Id(), id)
+	}
+	if diags := resourceDynDsfRecordRead(ctx, record, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if line := record.Get("master_line").(string); line != "192.168.0.10" {
+		t.Fatalf("unexpected master line: %s", line)
+	}
+
+	record.Set("master_line", "192.168.0.11")
+	if diags := resourceDynDsfRecordUpdate(ctx, record, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if line := record.Get("master_line").(string); line != "192.168.0.11" {
+		t.Fatalf("unexpected master line: %s", line)
+	}
+
+	if err := resourceDynDSFRulesetDelete(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFRulesetRead(ruleset, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ruleset.Id() != "" {
+		t.Fatal("expected the deleted ruleset to be removed from state")
+	}
+	if diags := resourceDynDsfRecordDelete(ctx, record, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if diags := resourceDynDSFRecordSetDelete(ctx, recordSet, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if err := resourceDynDSFRsfcDelete(rsfc, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFResponsePoolDelete(pool, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynDSFMonitorDelete(monitor, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynTrafficDirectorDelete(td, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := resourceDynTrafficDirectorRead(td, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if td.Id() != "" {
+		t.Fatal("expected the deleted traffic director to be removed from state")
+	}
+}
+
+func TestDynTrafficDirector_import(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	ctx := context.Background()
+
+	td := testCreateTrafficDirector(t, map[string]interface{}{
+		"label": "my-traffic-director",
+		"ttl":   300,
+	}, provider)
+	chain := testCreateTrafficDirectorChain(t, td, provider)
+	monitor, pool, rsfc, recordSet, record := chain.monitor, chain.pool, chain.rsfc, chain.recordSet, chain.record
+
+	cases := []struct {
+		resource *schema.Resource
+		id       string
+		expected *schema.ResourceData
+		attrs    []string
+	}{
+		{resourceDynTrafficDirector(), td.Id(), td, []string{"label", "ttl"}},
+		{resourceDynDSFMonitor(), monitor.Id(), monitor, []string{"label", "protocol", "probe_interval"}},
+		{resourceDynDSFResponsePool(), td.Id() + "/" + pool.Id(), pool, []string{"label", "automation", "traffic_director_id"}},
+		{resourceDynDSFRsfc(), td.Id() + "/" + rsfc.Id(), rsfc, []string{"label", "traffic_director_id", "response_pool_id"}},
+		{resourceDynDSFRecordSet(), td.Id() + "/" + recordSet.Id(), recordSet, []string{"label", "traffic_director_id", "response_pool_id", "dsf_rsfc_id", "monitor_id"}},
+		{resourceDynDsfRecord(), td.Id() + "/" + record.Id(), record, []string{"label", "traffic_director_id", "record_set_id", "master_line"}},
+	}
+	for _, tc := range cases {
+		imported := testImportResource(t, tc.resource, tc.id, provider)
+		if imported.Id() != tc.expected.Id() {
+			t.Errorf("%s: unexpected ID %s, expected %s", tc.id, imported.Id(), tc.expected.Id())
+		}
+		for _, attr := range tc.attrs {
+			if actual, expected := imported.Get(attr), tc.expected.Get(attr); actual != expected {
+				t.Errorf("%s: unexpected %s %v, expected %v", tc.id, attr, actual, expected)
+			}
+		}
+	}
+
+	invalid := resourceDynDSFRecordSet().TestResourceData()
+	invalid.SetId(td.Id() + "/" + rsfc.Id())
+	if _, err := resourceDynDSFRecordSetImportState(ctx, invalid, provider); err == nil {
+		t.Error("expected the import of a missing record set to fail")
+	}
+}
+
+// testCreateTrafficDirector creates a Traffic Director service from config.
+func testCreateTrafficDirector(t *testing.T, config map[string]interface{}, meta interface{}) *schema.ResourceData {
+	td := schema.TestResourceDataRaw(t, resourceDynTrafficDirector().Schema, config)
+	if err := resourceDynTrafficDirectorCreate(td, meta); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return td
+}
+
+// testTrafficDirectorChain holds the objects serving the answers of a
+// Traffic Director service, from its response pool down to a record.
+type testTrafficDirectorChain struct {
+	monitor   *schema.ResourceData
+	pool      *schema.ResourceData
+	rsfc      *schema.ResourceData
+	recordSet *schema.ResourceData
+	record    *schema.ResourceData
+}
+
+// testCreateTrafficDirectorChain creates a response pool in the service td,
+// with a record set failover chain, a record set monitored by a new monitor,
+// and a record.
+func testCreateTrafficDirectorChain(t *testing.T, td *schema.ResourceData, meta interface{}) testTrafficDirectorChain {
+	ctx := context.Background()
+	var chain testTrafficDirectorChain
+
+	chain.monitor = schema.TestResourceDataRaw(t, resourceDynDSFMonitor().Schema, map[string]interface{}{
+		"label":          "my-monitor",
+		"protocol":       "HTTP",
+		"response_count": 1,
+		"probe_interval": 60,
+		"retries":        1,
+	})
+	if err := resourceDynDSFMonitorCreate(chain.monitor, meta); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	chain.pool = schema.TestResourceDataRaw(t, resourceDynDSFResponsePool().Schema, map[string]interface{}{
+		"label":               "my-response-pool",
+		"automation":          "auto",
+		"traffic_director_id": td.Id(),
+	})
+	if err := resourceDynDSFResponsePoolCreate(chain.pool, meta); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	chain.rsfc = schema.TestResourceDataRaw(t, resourceDynDSFRsfc().Schema, map[string]interface{}{
+		"label":               "my-rsfc",
+		"traffic_director_id": td.Id(),
+		"response_pool_id":    chain.pool.Id(),
+	})
+	if err := resourceDynDSFRsfcCreate(chain.rsfc, meta); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	chain.recordSet = schema.TestResourceDataRaw(t, resourceDynDSFRecordSet().Schema, map[string]interface{}{
+		"label":               "my-record-set",
+		"traffic_director_id": td.Id(),
+		"response_pool_id":    chain.pool.Id(),
+		"dsf_rsfc_id":         chain.rsfc.Id(),
+		"rdata_class":         "A",
+		"monitor_id":          chain.monitor.Id(),
+	})
+	if diags := resourceDynDSFRecordSetCreate(ctx, chain.recordSet, meta); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+
+	chain.record = schema.TestResourceDataRaw(t, resourceDynDsfRecord().Schema, map[string]interface{}{
+		"label":               "my-record",
+		"traffic_director_id": td.Id(),
+		"record_set_id":       chain.recordSet.Id(),
+		"automation":          "auto",
+		"master_line":         "192.168.0.10",
+	})
+	if diags := resourceDynDsfRecordCreate(ctx, chain.record, meta); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	return chain
+}
+
+// testImportResource imports and reads a resource like terraform import.
+func testImportResource(t *testing.T, r *schema.Resource, id string, meta interface{}) *schema.ResourceData {
+	ctx := context.Background()
+	d := r.TestResourceData()
+	d.SetId(id)
+
+	var results []*schema.ResourceData
+	var err error
+	if r.Importer.StateContext != nil {
+		results, err = r.Importer.StateContext(ctx, d, meta)
+	} else {
+		results, err = r.Importer.State(d, meta)
+	}
+	if err != nil {
+		t.Fatalf("%s: err: %s", id, err)
+	}
+	d = results[0]
+
+	if r.ReadContext != nil {
+		if diags := r.ReadContext(ctx, d, meta); diags.HasError() {
+			t.Fatalf("%s: err: %#v", id, diags)
+		}
+	} else if err := r.Read(d, meta); err != nil {
+		t.Fatalf("%s: err: %s", id, err)
+	}
+	return d
+}
+
+// testPlanResource returns the data a resource is updated with when config
+// is applied over its current state, like terraform apply.
+func testPlanResource(t *testing.T, r *schema.Resource, d *schema.ResourceData, config map[string]interface{}, meta interface{}) *schema.ResourceData {
+	state := d.State()
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), meta)
+	if err != nil {
+		t.Fatalf("%s: err: %s", d.Id(), err)
+	}
+	planned, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("%s: err: %s", d.Id(), err)
+	}
+	return planned
+}
@@ -0,0 +1,173 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceDynZone() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages a primary zone, and the contact, TTL and serial style of its SOA record.",
+
+		CreateContext: resourceDynZoneCreate,
+		ReadContext:   resourceDynZoneRead,
+		UpdateContext: resourceDynZoneUpdate,
+		DeleteContext: resourceDynZoneDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the zone, e.g. `example.com`.",
+			},
+			"rname": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+				Description:  "Email of the administrative contact of the zone, e.g. `hostmaster@example.com`.",
+			},
+			"ttl": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Default TTL of the zone, set on its SOA record. Defaults to 3600.",
+			},
+			"serial_style": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "increment",
+				ValidateFunc: validation.StringInSlice([]string{"increment", "epoch", "day", "minute"}, false),
+				Description: `Style of the serial of the zone, updated on every publish. Defaults to increment.
+  * increment — Serials are incremented by 1.
+  * epoch — Serials are the UNIX timestamp of the publish.
+  * day — Serials are the date of the publish, as YYYYMMDDxx.
+  * minute — Serials are the time of the publish, as YYMMDDHHMM.`,
+			},
+			"serial": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Current serial of the zone.",
+			},
+			"zone_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of the zone, `Primary` or `Secondary`.",
+			},
+			"publish_notes": publishNotesSchema(),
+		},
+	}
+}
+
+func expandZone(d *schema.ResourceData) *api.Zone {
+	return &api.Zone{
+		Name:        d.Get("zone").(string),
+		RName:       d.Get("rname").(string),
+		TTL:         d.Get("ttl").(int),
+		SerialStyle: d.Get("serial_style").(string),
+	}
+}
+
+func resourceDynZoneCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+
+	zone := expandZone(d)
+	log.Printf("[DEBUG] Dyn zone create configuration: %#v", zone)
+
+	err := provider.changeZone(ctx, zone.Name, provider.publishNotes(d, "dyn_zone"), func(client *api.ConvenientClient) error {
+		if err := client.CreateZoneContext(ctx, zone); err != nil {
+			return fmt.Errorf("Failed to create Dyn zone: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(zone.Name)
+
+	return resourceDynZoneRead(ctx, d, meta)
+}
+
+func resourceDynZoneRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	return readDynZone(ctx, d, client)
+}
+
+func readDynZone(ctx context.Context, d *schema.ResourceData, client *api.ConvenientClient) diag.Diagnostics {
+	zone := &api.Zone{Name: d.Id()}
+	err := client.GetZoneContext(ctx, zone)
+	if err != nil {
+		if api.IsNotFound(err) {
+			log.Printf("[WARN] Dyn zone %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("Couldn't find Dyn zone: %s", err)
+	}
+
+	d.Set("zone", zone.Name)
+	d.Set("rname", zone.RName)
+	d.Set("ttl", zone.TTL)
+	d.Set("serial_style", zone.SerialStyle)
+	d.Set("serial", zone.Serial)
+	d.Set("zone_type", zone.Type)
+
+	return nil
+}
+
+func resourceDynZoneUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+
+	zone := expandZone(d)
+	log.Printf("[DEBUG] Dyn zone update configuration: %#v", zone)
+
+	err := provider.changeZone(ctx, zone.Name, provider.publishNotes(d, "dyn_zone"), func(client *api.ConvenientClient) error {
+		if err := client.UpdateZoneContext(ctx, zone); err != nil {
+			return fmt.Errorf("Failed to update Dyn zone: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceDynZoneRead(ctx, d, meta)
+}
+
+func resourceDynZoneDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider := GetProvider(meta)
+	client, err := provider.GetClientContext(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer provider.PutClient(client)
+
+	log.Printf("[INFO] Deleting Dyn zone: %s", d.Id())
+
+	err = client.DeleteZoneContext(ctx, &api.Zone{Name: d.Id()})
+	if err != nil {
+		if api.IsNotFound(err) {
+			return nil
+		}
+		return diag.Errorf("Failed to delete Dyn zone: %s", err)
+	}
+
+	return nil
+}
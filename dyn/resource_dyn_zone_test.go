@@ -0,0 +1,78 @@
+package dyn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynZone_lifecycle(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	provider := testDynProvider(t, server)
+	ctx := context.Background()
+
+	d := schema.TestResourceDataRaw(t, resourceDynZone().Schema, map[string]interface{}{
+		"zone":  "example.org",
+		"rname": "hostmaster@example.org",
+		"ttl":   1800,
+	})
+	if diags := resourceDynZoneCreate(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if d.Id() != "example.org" {
+		t.Fatalf("unexpected ID: %s", d.Id())
+	}
+	if style := d.Get("serial_style").(string); style != "increment" {
+		t.Fatalf("unexpected serial style: %s", style)
+	}
+	if zoneType := d.Get("zone_type").(string); zoneType != "Primary" {
+		t.Fatalf("unexpected zone type: %s", zoneType)
+	}
+
+	// Records can be managed in the new zone
+	record := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":  "example.org",
+		"name":  "www",
+		"type":  "A",
+		"value": "192.168.0.10",
+	})
+	if err := resourceDynRecordCreate(record, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d.Set("rname", "dns@example.org")
+	d.Set("ttl", 900)
+	d.Set("serial_style", "epoch")
+	if diags := resourceDynZoneUpdate(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+
+	// Import by zone name
+	imported := schema.TestResourceDataRaw(t, resourceDynZone().Schema, map[string]interface{}{})
+	imported.SetId("example.org")
+	if diags := resourceDynZoneRead(ctx, imported, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if rname := imported.Get("rname").(string); rname != "dns@example.org" {
+		t.Fatalf("unexpected rname: %s", rname)
+	}
+	if ttl := imported.Get("ttl").(int); ttl != 900 {
+		t.Fatalf("unexpected TTL: %d", ttl)
+	}
+	if style := imported.Get("serial_style").(string); style != "epoch" {
+		t.Fatalf("unexpected serial style: %s", style)
+	}
+
+	if diags := resourceDynZoneDelete(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if diags := resourceDynZoneRead(ctx, d, provider); diags.HasError() {
+		t.Fatalf("err: %#v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatal("expected the deleted zone to be removed from state")
+	}
+}
@@ -0,0 +1,254 @@
+package dyn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+)
+
+// Time to wait for more changes to a zone after the last one, before
+// publishing them at once.
+const zonePublishDelay = time.Second
+
+// zoneBatch gathers the changes made to a zone by several resources, so that
+// they are published at once. Dyn keeps the pending changes of each session,
+// so they are all made with the session of the batch.
+type zoneBatch struct {
+	zone string
+
+	// ready is closed once the session of the batch is open, or failed to
+	// open with err.
+	ready  chan struct{}
+	client *api.ConvenientClient
+	// changeMutex serializes the changes made with client.
+	changeMutex sync.Mutex
+
+	// Number of changes being made, and staged so far, time of the last one,
+	// and distinct publish notes of the staged changes. They are guarded by
+	// the mutex of the provider, and idle is signaled when no change is
+	// being made anymore.
+	changing   int
+	idle       *sync.Cond
+	staged     int
+	lastChange time.Time
+	notes      []string
+
+	// done is closed once the batch is published, or failed with err.
+	done chan struct{}
+	err  error
+}
+
+// changeZone stages a change to a zone with change, and waits for it to be
+// published along with the changes made to the zone by other resources in
+// the meantime.
+//
+// The zone is published once no change has been made to it for
+// publishDelay, with the notes of its changes, and the error of the publish
+// is returned to every resource whose change it contains. An error of change
+// is returned as is, and the change is left out of the publish.
+//
+// The batch is published in the background, independently of the context of
+// the resource which started it: ctx only bounds the wait of the caller, whose
+// staged change is still published with the batch if ctx is done first.
+func (p *DynProvider) changeZone(ctx context.Context, zone, notes string, change func(*api.ConvenientClient) error) error {
+	p.mutex.Lock()
+	batch, ok := p.zoneBatches[zone]
+	if !ok {
+		batch = &zoneBatch{
+			zone:  zone,
+			ready: make(chan struct{}),
+			idle:  sync.NewCond(&p.mutex),
+			done:  make(chan struct{}),
+		}
+		if p.zoneBatches == nil {
+			p.zoneBatches = make(map[string]*zoneBatch)
+		}
+		p.zoneBatches[zone] = batch
+		go p.publishZoneBatch(p.ctx, batch)
+	}
+	batch.changing++
+	batch.lastChange = time.Now()
+	p.mutex.Unlock()
+
+	select {
+	case <-batch.ready:
+	case <-ctx.Done():
+		p.endZoneChange(batch, false, "")
+		return ctx.Err()
+	}
+	if batch.client == nil {
+		return batch.err
+	}
+
+	batch.changeMutex.Lock()
+	err := change(batch.client)
+	batch.changeMutex.Unlock()
+	p.endZoneChange(batch, err == nil, notes)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if batch.err != nil {
+		return fmt.Errorf("Failed to publish Dyn zone: %w", batch.err)
+	}
+	return nil
+}
+
+func (p *DynProvider) endZoneChange(batch *zoneBatch, staged bool, notes string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	batch.changing--
+	batch.lastChange = time.Now()
+	if batch.changing == 0 {
+		batch.idle.Broadcast()
+	}
+	if !staged {
+		return
+	}
+	batch.staged++
+	if notes == "" {
+		return
+	}
+	for _, n := range batch.notes {
+		if n == notes {
+			return
+		}
+	}
+	batch.notes = append(batch.notes, notes)
+}
+
+// publishZoneBatch opens the session of a batch, and publishes the batch
+// once no change has been made to it for publishDelay. ctx is the context of
+// the provider, the batch serving every resource which joins it.
+func (p *DynProvider) publishZoneBatch(ctx context.Context, batch *zoneBatch) {
+	defer close(batch.done)
+
+	client, err := p.GetClientContext(ctx)
+	if err == nil {
+		err = p.checkZoneChanges(ctx, client, batch.zone)
+		if err != nil {
+			// Do not hand the changes over to the next user of the session
+			p.logoutClient(client)
+		}
+	}
+	if err != nil {
+		p.mutex.Lock()
+		delete(p.zoneBatches, batch.zone)
+		p.mutex.Unlock()
+		batch.err = err
+		close(batch.ready)
+		return
+	}
+	defer func() {
+		if ctx.Err() != nil {
+			// The provider already logged out of its idle sessions
+			p.logoutClient(client)
+			return
+		}
+		p.PutClient(client)
+	}()
+	batch.client = client
+	// The changes staged in the session are lost if it expires, the client
+	// logging in again with a new token
+	token := client.Token
+	close(batch.ready)
+
+	for batch.err == nil {
+		p.mutex.Lock()
+		wait := p.publishDelay - time.Since(batch.lastChange)
+		if batch.changing == 0 && wait <= 0 {
+			// Later changes go to a new batch
+			delete(p.zoneBatches, batch.zone)
+			p.mutex.Unlock()
+			break
+		}
+		p.mutex.Unlock()
+		if wait <= 0 {
+			wait = p.publishDelay
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			// The provider is closing
+			p.mutex.Lock()
+			delete(p.zoneBatches, batch.zone)
+			p.mutex.Unlock()
+			batch.err = ctx.Err()
+		}
+	}
+
+	// The changes being made when the provider closes are not interrupted:
+	// wait for them before discarding them and releasing the session
+	p.mutex.Lock()
+	for batch.changing > 0 {
+		batch.idle.Wait()
+	}
+	staged := batch.staged
+	notes := strings.Join(batch.notes, "; ")
+	p.mutex.Unlock()
+
+	if staged == 0 {
+		return
+	}
+	switch {
+	case batch.err != nil:
+		// The provider closed before the publish
+	case client.Token != token:
+		batch.err = fmt.Errorf("%w: the session expired while changing zone %s", api.ErrStagedChangesLost, batch.zone)
+	default:
+		log.Printf("[DEBUG] Publishing %d changes to Dyn zone %s", staged, batch.zone)
+		batch.err = client.PublishZoneNotesContext(ctx, batch.zone, notes)
+	}
+	if batch.err != nil {
+		// Do not leave the changes to the next user of the session
+		if err := client.DiscardZoneChanges(batch.zone); err != nil {
+			log.Printf("[WARN] Failed to discard the changes to Dyn zone %s: %s", batch.zone, err)
+		}
+	}
+}
+
+// checkZoneChanges makes sure that a session has no unpublished changes to a
+// zone before a batch stages its own, so that it does not publish changes
+// which were not made by its resources, e.g. left in a pooled session by an
+// operation which failed. Depending on the pending_zone_changes setting, the
+// changes are discarded or listed in an error.
+//
+// Every zone publish of the provider goes through a batch, and so through
+// this check.
+func (p *DynProvider) checkZoneChanges(ctx context.Context, client *api.ConvenientClient, zone string) error {
+	changes, err := client.GetZoneChangesContext(ctx, zone)
+	if err != nil {
+		if api.IsNotFound(err) {
+			// The zone is being created
+			return nil
+		}
+		return fmt.Errorf("Failed to get the pending changes of Dyn zone %s: %s", zone, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var list strings.Builder
+	for _, change := range changes {
+		fmt.Fprintf(&list, "\n  - %s", change)
+	}
+	if p.discardZoneChanges {
+		log.Printf("[WARN] Discarding %d unpublished changes to Dyn zone %s found in the session:%s", len(changes), zone, list.String())
+		if err := client.DiscardZoneChangesContext(ctx, zone); err != nil {
+			return fmt.Errorf("Failed to discard the pending changes of Dyn zone %s: %s", zone, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("Found %d unpublished changes to Dyn zone %s in the session, which were not made by this resource:%s\n"+
+		"Set pending_zone_changes to \"discard\" in the provider to discard them", len(changes), zone, list.String())
+}
@@ -0,0 +1,243 @@
+package dyn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cdiscount/terraform-provider-dyn/api"
+	"github.com/Cdiscount/terraform-provider-dyn/api/dyntest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDynProvider_changeZone(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	server.AddZone("example.org")
+	provider := testDynProvider(t, server)
+	provider.publishDelay = 50 * time.Millisecond
+
+	newRecord := func(zone, name string) *schema.ResourceData {
+		return schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+			"zone":  zone,
+			"name":  name,
+			"type":  "A",
+			"value": "192.168.0.10",
+		})
+	}
+	createRecords := func(records ...*schema.ResourceData) []error {
+		errs := make([]error, len(records))
+		var wg sync.WaitGroup
+		for i, d := range records {
+			wg.Add(1)
+			go func(i int, d *schema.ResourceData) {
+				defer wg.Done()
+				errs[i] = resourceDynRecordCreate(d, provider)
+			}(i, d)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	// Changes made at once are published once per zone
+	var records []*schema.ResourceData
+	for i := 0; i < 5; i++ {
+		records = append(records, newRecord("example.com", fmt.Sprintf("www%d", i)))
+	}
+	records = append(records, newRecord("example.org", "www"))
+	for _, err := range createRecords(records...) {
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	for _, d := range records {
+		if d.Id() == "" {
+			t.Fatalf("expected an ID to be set for %s", d.Get("name"))
+		}
+	}
+	if n := server.CountRequests("PUT", "Zone/example.com"); n != 1 {
+		t.Fatalf("expected a single publish of example.com, got %d", n)
+	}
+	if s := server.Serial("example.com"); s != 2 {
+		t.Fatalf("expected a single serial bump, got serial %d", s)
+	}
+	if s := server.Serial("example.org"); s != 2 {
+		t.Fatalf("expected example.org to be published, got serial %d", s)
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 6 {
+		t.Fatalf("expected 6 records in example.com, got %d", n)
+	}
+
+	// A failed change is left out of the publish
+	failed := newRecord("example.com", "bad")
+	failed.Set("value", "not an address")
+	errs := createRecords(newRecord("example.com", "mail"), failed)
+	if errs[0] != nil {
+		t.Fatalf("err: %s", errs[0])
+	}
+	if errs[1] == nil || !strings.Contains(errs[1].Error(), "Failed to create Dyn record") {
+		t.Fatalf("expected the change to fail, got %v", errs[1])
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 7 {
+		t.Fatalf("expected 7 records in example.com, got %d", n)
+	}
+
+	// A failed publish is reported to every change it contains
+	server.FailPublish("example.com", true)
+	errs = createRecords(newRecord("example.com", "ftp"), newRecord("example.com", "smtp"))
+	for _, err := range errs {
+		if err == nil || !strings.Contains(err.Error(), "Failed to publish Dyn zone: ") {
+			t.Fatalf("expected the publish to fail, got %v", err)
+		}
+	}
+	if n := server.CountRequests("PUT", "Zone/example.com"); n != 3 {
+		t.Fatalf("expected a single publish per batch, got %d publishes", n)
+	}
+}
+
+func TestDynProvider_pendingZoneChanges(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	// Leave a change in the pooled session, as a failed operation would
+	client, err := provider.GetClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	stray := &api.Record{Zone: "example.com", Name: "stray", Type: "A", Value: "192.168.0.99"}
+	if err := client.CreateRecord(stray); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider.PutClient(client)
+
+	d := schema.TestResourceDataRaw(t, resourceDynRecord().Schema, map[string]interface{}{
+		"zone":  "example.com",
+		"name":  "www",
+		"type":  "A",
+		"value": "192.168.0.10",
+	})
+	err = resourceDynRecordCreate(d, provider)
+	if err == nil || !strings.Contains(err.Error(), "\n  - A stray.example.com 192.168.0.99\n") {
+		t.Fatalf("expected the pending change to be reported, got %v", err)
+	}
+	if s := server.Serial("example.com"); s != 1 {
+		t.Fatalf("expected the zone not to be published, got serial %d", s)
+	}
+	// The session holding the changes is not reused
+	if n := server.CountRequests("DELETE", "Session"); n != 1 {
+		t.Fatalf("expected the session to be logged out, got %d logouts", n)
+	}
+	if len(provider.clients) != 0 || provider.sessions != 0 {
+		t.Fatalf("expected the session to be dropped, got %d idle of %d sessions", len(provider.clients), provider.sessions)
+	}
+
+	// Another session holding changes
+	client, err = provider.GetClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.CreateRecord(stray); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provider.PutClient(client)
+
+	provider.discardZoneChanges = true
+	if err := resourceDynRecordCreate(d, provider); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	records := server.PublishedRecords("example.com")
+	if len(records) != 2 || records[1].FQDN != "www.example.com" {
+		t.Fatalf("expected only the new record to be published, got %#v", records)
+	}
+}
+
+func TestDynProvider_changeZoneSessionExpiry(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+
+	// The session expires between two changes of the batch, losing the first
+	err := provider.changeZone(context.Background(), "example.com", "", func(client *api.ConvenientClient) error {
+		if err := client.CreateRecord(&api.Record{Zone: "example.com", Name: "www", Type: "A", Value: "192.168.0.10"}); err != nil {
+			return err
+		}
+		server.ExpireSessions()
+		return client.CreateRecord(&api.Record{Zone: "example.com", Name: "mail", Type: "A", Value: "192.168.0.20"})
+	})
+	if !api.IsStagedChangesLost(err) {
+		t.Fatalf("expected the lost changes to be reported, got %v", err)
+	}
+	if s := server.Serial("example.com"); s != 1 {
+		t.Fatalf("expected the zone not to be published, got serial %d", s)
+	}
+}
+
+func TestDynProvider_changeZoneCancel(t *testing.T) {
+	server := dyntest.NewServer()
+	defer server.Close()
+	server.AddZone("example.com")
+	provider := testDynProvider(t, server)
+	provider.publishDelay = 50 * time.Millisecond
+
+	// The resource starting the batch gives up once its change is staged,
+	// while another one joins the batch
+	ctx, cancel := context.WithCancel(context.Background())
+	joined := make(chan error, 1)
+	err := provider.changeZone(ctx, "example.com", "", func(client *api.ConvenientClient) error {
+		go func() {
+			joined <- provider.changeZone(context.Background(), "example.com", "", func(client *api.ConvenientClient) error {
+				return client.CreateRecord(&api.Record{Zone: "example.com", Name: "mail", Type: "A", Value: "192.168.0.20"})
+			})
+		}()
+		cancel()
+		return client.CreateRecord(&api.Record{Zone: "example.com", Name: "www", Type: "A", Value: "192.168.0.10"})
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected the wait to be cancelled, got %v", err)
+	}
+
+	// The batch is still published for the other resource
+	if err := <-joined; err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 3 {
+		t.Fatalf("expected both changes to be published, got %d records", n)
+	}
+
+	// A change still being made when the provider closes is waited for,
+	// then discarded with its session instead of being pooled
+	closing := testDynProvider(t, server)
+	started := make(chan struct{})
+	resume := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- closing.changeZone(context.Background(), "example.com", "", func(client *api.ConvenientClient) error {
+			close(started)
+			<-resume
+			return client.CreateRecord(&api.Record{Zone: "example.com", Name: "ftp", Type: "A", Value: "192.168.0.30"})
+		})
+	}()
+	<-started
+	closing.Close()
+	time.Sleep(2 * closing.publishDelay)
+	close(resume)
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the publish to be cancelled, got %v", err)
+	}
+	if n := len(server.PublishedRecords("example.com")); n != 3 {
+		t.Fatalf("expected the change not to be published, got %d records", n)
+	}
+	closing.mutex.Lock()
+	defer closing.mutex.Unlock()
+	if len(closing.clients) != 0 || closing.sessions != 0 {
+		t.Fatalf("expected the session to be logged out, got %d idle of %d sessions", len(closing.clients), closing.sessions)
+	}
+}
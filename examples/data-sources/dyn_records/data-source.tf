@@ -0,0 +1,9 @@
+data "dyn_records" "ns" {
+  zone = "example.com"
+  fqdn = "example.com"
+  type = "NS"
+}
+
+output "name_servers" {
+  value = data.dyn_records.ns.records[*].value
+}
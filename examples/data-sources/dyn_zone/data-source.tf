@@ -0,0 +1,7 @@
+data "dyn_zone" "example" {
+  zone = "example.com"
+}
+
+output "serial" {
+  value = data.dyn_zone.example.serial
+}
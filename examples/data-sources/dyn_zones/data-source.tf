@@ -0,0 +1,15 @@
+data "dyn_zones" "com" {
+  name_regex = "\\.com$"
+}
+
+resource "dyn_record" "caa" {
+  for_each = toset(data.dyn_zones.com.names)
+
+  zone = each.value
+  type = "CAA"
+
+  caa {
+    tag   = "issue"
+    value = "letsencrypt.org"
+  }
+}
@@ -0,0 +1,2 @@
+# Monitors are imported with their ID
+terraform import dyn_dsf_monitor.monitor abcdef
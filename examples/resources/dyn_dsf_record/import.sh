@@ -0,0 +1,3 @@
+# Records are imported with their traffic director ID and their ID, their
+# record set being read back
+terraform import dyn_dsf_record.my-record abcdef/ghijkl
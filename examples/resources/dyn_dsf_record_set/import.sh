@@ -0,0 +1,3 @@
+# Record sets are imported with their traffic director ID and their ID, their
+# response pool and record set failover chain being looked up
+terraform import dyn_dsf_record_set.record_set abcdef/ghijkl
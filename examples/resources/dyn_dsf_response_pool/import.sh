@@ -0,0 +1,2 @@
+# Response pools are imported with their traffic director ID and their ID
+terraform import dyn_dsf_response_pool.response_pool abcdef/ghijkl
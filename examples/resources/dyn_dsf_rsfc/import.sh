@@ -0,0 +1,3 @@
+# Record set failover chains are imported with their traffic director ID and
+# their ID, their response pool being read back
+terraform import dyn_dsf_rsfc.rsfc abcdef/ghijkl
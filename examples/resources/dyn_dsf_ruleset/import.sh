@@ -0,0 +1,2 @@
+# Rulesets are imported with their traffic director ID and their ID
+terraform import dyn_dsf_ruleset.ruleset abcdef/ghijkl
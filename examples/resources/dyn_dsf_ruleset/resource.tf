@@ -26,3 +26,23 @@ resource "dyn_dsf_ruleset" "ruleset" {
     dyn_dsf_response_pool.response_pool2.id,
   ]
 }
+
+resource "dyn_dsf_response_pool" "europe" {
+  label               = "my-european-response-pool"
+  traffic_director_id = dyn_traffic_director.example.id
+}
+
+resource "dyn_dsf_ruleset" "europe" {
+  label               = "my-european-ruleset"
+  traffic_director_id = dyn_traffic_director.example.id
+  criteria_type       = "geoip"
+
+  geoip {
+    region  = ["15", "16"]
+    country = ["GB"]
+  }
+
+  response_pool_ids = [
+    dyn_dsf_response_pool.europe.id,
+  ]
+}
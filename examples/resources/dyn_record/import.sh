@@ -0,0 +1,4 @@
+# Records are imported with their type, zone and fully qualified domain name,
+# followed by their ID or by their value when several records share the name
+terraform import dyn_record.www A/example.com/www.example.com/123456789
+terraform import dyn_record.www A/example.com/www.example.com/value=192.168.0.10
@@ -0,0 +1,2 @@
+# Record sets are imported with their type, zone and fully qualified domain name
+terraform import dyn_record_set.www A/example.com/www.example.com
@@ -0,0 +1,7 @@
+resource "dyn_record_set" "www" {
+  zone   = "example.com"
+  name   = "www"
+  type   = "A"
+  ttl    = "300"
+  values = ["192.168.0.10", "192.168.0.11"]
+}
@@ -0,0 +1,2 @@
+# Traffic Directors are imported with their service ID
+terraform import dyn_traffic_director.example abcdef
@@ -0,0 +1,16 @@
+# With stage_traffic_director_changes = true in the provider, the objects of
+# the service are published at once, once they are all applied. Every object
+# feeds the triggers, so that its updates are published too, leaving out the
+# pending_change of the service which the publish itself changes.
+resource "dyn_traffic_director_publish" "example" {
+  traffic_director_id = dyn_traffic_director.example.id
+
+  triggers = {
+    service = jsonencode({ for k, v in dyn_traffic_director.example : k => v if k != "pending_change" })
+    pool    = jsonencode(dyn_dsf_response_pool.example)
+    rsfc    = jsonencode(dyn_dsf_rsfc.example)
+    rset    = jsonencode(dyn_dsf_record_set.example)
+    record  = jsonencode(dyn_dsf_record.example)
+    rules   = jsonencode(dyn_dsf_ruleset.example)
+  }
+}
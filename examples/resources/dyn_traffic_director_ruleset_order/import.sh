@@ -0,0 +1,2 @@
+# The order of the rulesets is imported with the traffic director ID
+terraform import dyn_traffic_director_ruleset_order.example abcdef
@@ -0,0 +1,9 @@
+# The european ruleset is evaluated before the catch-all one
+resource "dyn_traffic_director_ruleset_order" "example" {
+  traffic_director_id = dyn_traffic_director.example.id
+
+  ruleset_ids = [
+    dyn_dsf_ruleset.europe.id,
+    dyn_dsf_ruleset.ruleset.id,
+  ]
+}
@@ -0,0 +1,2 @@
+# Traffic Director services are imported with their service ID
+terraform import dyn_traffic_director_service.example abcdef
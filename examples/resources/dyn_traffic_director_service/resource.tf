@@ -0,0 +1,74 @@
+resource "dyn_dsf_monitor" "monitor" {
+  label          = "my-monitor"
+  protocol       = "HTTPS"
+  response_count = 2
+  probe_interval = 60
+  retries        = 2
+}
+
+resource "dyn_traffic_director_service" "example" {
+  label = "my-traffic-director"
+  ttl   = 300
+
+  node {
+    zone = "my-zone.example.net"
+    fqdn = "example.my-zone.example.net"
+  }
+
+  ruleset {
+    label         = "europe"
+    criteria_type = "geoip"
+
+    geoip {
+      region = ["15", "16", "17"]
+    }
+
+    response_pool {
+      label = "europe"
+
+      rs_chain {
+        label = "europe"
+        core  = true
+
+        record_set {
+          label       = "paris"
+          rdata_class = "A"
+          monitor_id  = dyn_dsf_monitor.monitor.id
+
+          record {
+            label       = "paris-1"
+            master_line = "192.168.0.10"
+          }
+
+          record {
+            label       = "paris-2"
+            master_line = "192.168.0.11"
+          }
+        }
+      }
+    }
+  }
+
+  ruleset {
+    label = "all"
+
+    response_pool {
+      label = "all"
+
+      rs_chain {
+        label = "all"
+
+        record_set {
+          label       = "dallas"
+          rdata_class = "A"
+          monitor_id  = dyn_dsf_monitor.monitor.id
+
+          record {
+            label       = "dallas-1"
+            master_line = "192.168.1.10"
+          }
+        }
+      }
+    }
+  }
+}
@@ -0,0 +1,2 @@
+# Zones are imported with their name
+terraform import dyn_zone.example example.com
@@ -0,0 +1,13 @@
+resource "dyn_zone" "example" {
+  zone         = "example.com"
+  rname        = "hostmaster@example.com"
+  ttl          = 3600
+  serial_style = "increment"
+}
+
+resource "dyn_record" "www" {
+  zone  = dyn_zone.example.zone
+  name  = "www"
+  type  = "A"
+  value = "192.168.0.10"
+}
@@ -11,4 +11,7 @@ import (
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: dyn.Provider})
+
+	// Log out the Dyn sessions once Terraform is done with the plugin
+	dyn.Shutdown()
 }